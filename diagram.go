@@ -0,0 +1,103 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"image"
+	"image/color"
+	"math"
+	"rp2/sim"
+)
+
+// Defining the layout of the spacetime diagram.
+const (
+	// The width and height of the diagram panel in pixels
+	diagramSize = 260
+
+	// The distance from the centre of the diagram to its left and right edges in world units
+	diagramRange float64 = 20
+)
+
+// drawDiagram draws a spacetime diagram of the ship and the asteroids near it in a panel on the right of the screen.
+// The diagram is drawn in the rest frame of the asteroid field along the direction the camera frame is moving, with the
+// future upwards, so the axes of the camera's frame are tilted by its velocity.
+func (g *Game) drawDiagram(screen *ebiten.Image) {
+	w := g.world
+
+	// Interpolate the ship between the previous and current tick, as the world is drawn
+	shipPos, angPos := w.Ship.Interpolate(g.alpha)
+	facing := sim.Vector{X: math.Sin(angPos), Y: -math.Cos(angPos)}
+	d := sim.NewSpacetimeDiagram(shipPos, w.Time()+(g.alpha-1)*w.Dt, w.Frame(), facing, w.C)
+
+	// Everything is drawn to a sub image so that lines are clipped to the panel
+	x0, y0 := g.screenWidth-diagramSize-10, 140
+	panel := screen.SubImage(image.Rect(x0, y0, x0+diagramSize, y0+diagramSize)).(*ebiten.Image)
+	vector.DrawFilledRect(panel, float32(x0), float32(y0), diagramSize, diagramSize, color.RGBA{A: 200}, false)
+
+	// The current event of the ship is a quarter of the way down, leaving most of the panel for the past
+	origin := sim.Vector{X: float64(x0) + diagramSize/2, Y: float64(y0) + diagramSize/4}
+	scale := diagramSize / 2 / diagramRange
+
+	toScreen := func(p sim.Vector) sim.Vector {
+		return sim.Vector{X: origin.X + p.X*scale, Y: origin.Y - p.Y*scale}
+	}
+
+	// line draws a line through the origin in the direction of (x, ct), reaching past the edges of the panel
+	line := func(x, ct float64, clr color.Color) {
+		a := toScreen(sim.Vector{X: x, Y: ct}.Scl(-4 * diagramRange))
+		b := toScreen(sim.Vector{X: x, Y: ct}.Scl(4 * diagramRange))
+		vector.StrokeLine(panel, float32(a.X), float32(a.Y), float32(b.X), float32(b.Y), 1, clr, true)
+	}
+
+	// Draw the axes of the rest frame, the light cone and the axes of the camera frame
+	beta := d.Beta()
+	line(1, 0, colorFaint)
+	line(0, 1, colorFaint)
+	line(1, 1, colorLight)
+	line(1, -1, colorLight)
+	line(1, beta, colorTitle) // The line of simultaneity of the camera frame
+	line(beta, 1, colorTitle) // The time axis of the camera frame
+
+	// Draw the worldlines of the asteroids near the ship
+	indices, particles := w.Asteroids.ActiveIndices()
+
+	for k, i := range indices {
+		// Each asteroid is plotted at its copy nearest the ship, so worldlines don't jump across the seams
+		h, pos := w.Asteroids.History(i), particles[k].Pos
+		offset := w.Topology.Offset(shipPos, pos)
+		if h == nil || offset.Mag() > 2*diagramRange {
+			continue
+		}
+
+		drawWorldline(panel, d.Worldline(h, shipPos.Add(offset).Sub(pos)), toScreen, colorDefault)
+	}
+
+	// Draw the worldline of the ship
+	if !w.Ended {
+		drawWorldline(panel, d.Worldline(w.ShipTrail, sim.Vector{}), toScreen, colorSuccess)
+	}
+
+	vector.StrokeRect(panel, float32(x0), float32(y0), diagramSize, diagramSize, 1, colorDefault, false)
+	text.Draw(panel, "ct", guiFont, int(origin.X)+6, y0+22, colorFaint)
+	text.Draw(panel, "x", guiFont, x0+diagramSize-22, int(origin.Y)-6, colorFaint)
+}
+
+// drawWorldline draws the line through the projected points of a worldline, skipping points too close together to
+// make a difference on the screen
+func drawWorldline(dst *ebiten.Image, points []sim.Vector, toScreen func(sim.Vector) sim.Vector, clr color.Color) {
+	if len(points) == 0 {
+		return
+	}
+
+	prev := toScreen(points[0])
+	for i := 1; i < len(points); i++ {
+		p := toScreen(points[i])
+		if p.SqrDist(prev) < 4 && i < len(points)-1 {
+			continue
+		}
+
+		vector.StrokeLine(dst, float32(prev.X), float32(prev.Y), float32(p.X), float32(p.Y), 1, clr, true)
+		prev = p
+	}
+}
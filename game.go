@@ -3,19 +3,17 @@ package main
 import (
 	"bytes"
 	_ "embed"
-	"fmt"
 	"github.com/charmbracelet/log"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/examples/resources/fonts"
-	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/hajimehoshi/ebiten/v2/text"
 	"golang.org/x/image/colornames"
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/opentype"
-	"image/color"
 	"image/png"
 	"math"
 	"math/rand"
+	"rp2/sim"
 	"time"
 )
 
@@ -86,78 +84,70 @@ var (
 	colorDamage  = colornames.Red
 	colorSuccess = colornames.Limegreen
 	colorFailure = colornames.Red
+	colorClock   = colornames.Gold
+	colorLight   = colornames.Yellow
+	colorFaint   = colornames.Dimgray
 )
 
-// Initialising random numbers for shaders
-var (
-	shaderSeed = rand.Float64()*10000 - 5000
-)
-
-// Defining the constants used in the game.
-const (
-	// the seconds per tick
-	dt float64 = 1.0 / 60.0
-
-	// The scale factor of the physics engine
-	physScale float64 = 0.3
-)
+// maxFrameTime is the most real time in seconds that is simulated in a single update, so that the game slows down
+// rather than freezing when it can't keep up
+const maxFrameTime = 0.25
 
-// Game is the main struct of the (relativistic) asteroids clone
+// Game is the main struct of the (relativistic) asteroids clone, it adapts a World to ebiten
 type Game struct {
-	// Particles
-	ship      *Particle // The ship particle
-	asteroids *Pool     // The asteroids pool
-	bullets   *Pool     // The bullets pool
-	explosion *Pool     // The explosion particles pool
+	world    *sim.World    // The simulation of the current run
+	cfg      sim.Config    // The settings of the game
+	scenario *sim.Scenario // The scenario every run is set up from, nil for normal runs
+
+	// Sprite sheets for the pools of the world
+	asteroidSheet  *SpriteSheet // The sprite sheet for the asteroids
+	bulletSheet    *SpriteSheet // The sprite sheet for the bullets
+	explosionSheet *SpriteSheet // The sprite sheet for the explosion particles
 
 	// Graphical elements
-	thrusting    bool      // Whether the ship is thrusting
-	health       int       // The health of the ship
-	ammo         int       // The amount of ammo the ship has
-	score        int       // The player's score
-	highScore    int       // The high score of the player
-	newHighScore bool      // Whether the high score of the player has changed
-	mainMenu     bool      // Whether the main menu screen is shown
-	gameOver     bool      // Whether the game over screen is shown
-	bgScroll     float64   // The position of the starfield background in the main menu / game over screen
-	screenStart  time.Time // The time at which the current screen became visible
-	gameEnd      bool      // Whether the game has ended
-	gameEndTime  time.Time // The time at which the game ends after the player has died
+	scenes       []Scene // The stack of scenes, the scene on top is the one being updated
+	bgScroll     float64 // The position of the starfield background in the menu screens
+	retarded     bool    // Whether particles are drawn at their retarded positions
+	simultaneous bool    // Whether particles are drawn in their state at the ship's time in the ship's frame
+	doppler      bool    // Whether particles are tinted by their doppler shift
+	aberration   bool    // Whether particles are drawn at their aberrated positions
+	clocks       bool    // Whether the clocks of the asteroids are drawn over them
+	diagram      bool    // Whether the spacetime diagram is drawn
+
+	// Timing
+	lastUpdate  time.Time // The time of the previous update
+	delta       float64   // The seconds of real time since the previous update
+	accumulator float64   // The seconds of simulation time that have passed in real time but not been simulated yet
+	timeScale   float64   // How many seconds of simulation time pass in a second of real time
+	alpha       float64   // How far between the previous and current tick the world is drawn, from 0 to 1
 
 	// Important values
 	screenWidth  int // The width of the screen
 	screenHeight int // The height of the screen
 
-	// Physical constants
-	c float64 // The speed of light
-
-	// Interpolation variables
-	cLerpInitial float64       // The initial value of the speed of light when interpolating
-	cLerpTarget  float64       // The target value of the speed of light when interpolating
-	cLerpStart   time.Time     // The start time of interpolating the speed of light
-	cLerpTime    time.Duration // How long to interpolate the speed of light
-	cLerp        bool          // Whether the speed of light is interpolating
+	// Random numbers
+	seeds      *rand.Rand // The random source used to pick the seed of each run
+	shaderSeed float64    // The seed of the starfield shader
 
-	invincibilityStart    time.Time     // The start time of the invincibility
-	invincibilityDuration time.Duration // The duration of the invincibility
-	invincibility         bool          // Whether the ship is invincible
+	// Leaderboard
+	leaderboard *sim.Leaderboard // The table of the best runs
 
-	clock float64 // The time from the point of view of a stationary observer
-}
+	// Input
+	controls *Controls // The bindings of the player's keyboard and gamepads
 
-func (g *Game) beginCLerp(c float64) {
-	g.cLerpInitial = g.c
-	g.cLerpTarget = c
-	g.cLerpStart = time.Now()
-	g.cLerp = true
+	// Replays
+	recordPath string      // Where to save the replay of each run, empty if runs are not recorded
+	recording  *sim.Replay // The replay of the current run
+	playback   *sim.Replay // The replay being played back, nil when the player is in control
 }
 
-// Init initializes the game object
-func (g *Game) Init(screenWidth, screenHeight int) {
-	log.Debug("initialising game object", "screenWidth", screenWidth, "screenHeight", screenHeight)
-	g.screenWidth = screenWidth   // Initialize the width of the screen
-	g.screenHeight = screenHeight // Initialize the height of the screen
-	g.c = 299792458.0             // Initialize the speed of light
+// Init initializes the game object with the settings in cfg, seed is used to pick the seeds of the runs
+func (g *Game) Init(cfg sim.Config, seed int64) {
+	log.Debug("initialising game object", "screenWidth", cfg.WindowWidth, "screenHeight", cfg.WindowHeight, "seed", seed)
+	g.cfg = cfg
+	g.screenWidth = cfg.WindowWidth   // Initialize the width of the screen
+	g.screenHeight = cfg.WindowHeight // Initialize the height of the screen
+	g.seeds = rand.New(rand.NewSource(seed))
 
 	log.Debug("loading shader")
 
@@ -275,380 +265,171 @@ func (g *Game) Init(screenWidth, screenHeight int) {
 
 	log.Debug("all fonts loaded, initializing values")
 
-	g.highScore = 0
-	g.mainMenu = true
-	g.gameOver = false
-	g.bgScroll = 0
-	g.screenStart = time.Now()
-
-	g.cLerpTime = time.Second * 2
-	g.invincibilityDuration = time.Millisecond * 1000
-
-	log.Debug("game initialised")
-}
-
-func (g *Game) startGame() {
-	log.Debug("starting new game")
-	// Initialise the speed of light
-	g.c = 299792458.0
-
-	// Initialise the score and health
-	g.health = 3
-	g.score = 0
-	g.ammo = 10
-
-	// Initialize the ship
-	g.ship = new(Particle)
-	g.ship.Mass = 1
-	g.ship.Radius = 1
-
-	log.Debug("initialising asteroids pool")
-
-	// Initialize the asteroids
-	g.asteroids = newAsteroidPool(64, 20).
-		SetSpriteSheet(64, bigAsteroid, smallAsteroid) // Set the sprite for the asteroids
+	// Set the sprites for the pools
+	g.asteroidSheet = NewSpriteSheet(64, bigAsteroid, smallAsteroid)
+	g.bulletSheet = NewSpriteSheet(64, bullet)
+	g.explosionSheet = NewSpriteSheet(64, explosion)
 
-	log.Debug("initialising bullets pool")
+	// Create a world so that the menus have a speed of light to draw with
+	g.world = sim.NewWorld(g.seeds.Int63(), cfg)
+	g.shaderSeed = shaderSeed(g.world.Seed)
 
-	// Initialize the bullets
-	g.bullets = NewPool(256).
-		SetSpriteSheet(64, bullet). // Set the sprite for the bullets
-		EnforceLifetime(time.Second * 10). // Enforce a lifetime of 10 seconds
-		DisableCollision() // Disable collision between bullets
+	// Load the leaderboard, keeping it in memory only if there is nowhere to save it
+	if path, err := sim.UserConfigPath("leaderboard.json"); err != nil {
+		log.Warn("no config directory, the leaderboard will not be saved", "error", err)
+		g.leaderboard = &sim.Leaderboard{}
+	} else {
+		g.leaderboard = sim.LoadLeaderboard(path)
+	}
 
-	log.Debug("initialising explosion pool")
+	// Load the controls, keeping them in memory only if there is nowhere to save them
+	if path, err := sim.UserConfigPath("controls.json"); err != nil {
+		log.Warn("no config directory, the controls will not be saved", "error", err)
+		g.controls = DefaultControls()
+	} else {
+		g.controls = LoadControls(path)
+	}
 
-	// Initialize the explosion particles
-	g.explosion = NewPool(256).
-		SetSpriteSheet(64, explosion). // Set the sprite for the explosions
-		EnforceLifetime(time.Second * 1). // Enforce a lifetime of 1 second
-		FadeOverLifetime(). // Fade out the explosion particles over the lifetime of the particle
-		DisableCollision() // Disable collision between explosions
+	g.bgScroll = 0
+	g.timeScale = 1
+	g.retarded = cfg.Retarded
+	g.doppler = cfg.Doppler
+	g.aberration = cfg.Aberration
 
-	log.Debug("all pools initialised, starting game")
+	g.Push(&mainMenuScene{})
 
-	g.newHighScore = false
-	g.mainMenu = false
-	g.gameOver = false
-	g.screenStart = time.Now()
+	log.Debug("game initialised")
 }
 
-// endGame ends the game and checks if the score is higher than the high score
-func (g *Game) endGame() {
-	log.Debug("ending game")
+// RecordTo enables recording every run to a replay file at path
+func (g *Game) RecordTo(path string) {
+	log.Debug("recording runs", "path", path)
+	g.recordPath = path
+}
 
-	if g.score > g.highScore {
-		log.Debug("new high score", "score", g.score, "highScore", g.highScore)
-		g.highScore = g.score
-		g.newHighScore = true
-	}
+// Play starts a run that plays back the given replay
+func (g *Game) Play(r *sim.Replay) {
+	log.Debug("playing replay", "seed", r.Seed, "ticks", len(r.Inputs))
+	g.playback = r
+	g.startGame()
+}
 
-	g.gameEnd = true
-	g.gameEndTime = time.Now()
+// PlayScenario sets up every run from the scenario. The settings of the scenario should already be applied to the
+// config the game was initialised with.
+func (g *Game) PlayScenario(sc *sim.Scenario) {
+	log.Debug("playing scenario", "name", sc.Name)
+	g.scenario = sc
 }
 
-// gameUpdate is called every physics update whenever the game is being played
-func (g *Game) gameUpdate() error {
-	// If the game has ended, wait 5 seconds before moving to the gameOver screen
-	if time.Since(g.gameEndTime).Seconds() > 5 && g.gameEnd {
-		log.Debug("moving to game over screen")
-		g.gameEnd = false
-		g.gameOver = true
-		g.screenStart = time.Now()
-		return nil
-	} else if g.gameEnd {
-		g.asteroids.Update(g.ship.Vel, g.c, dt)
-		g.bullets.Update(g.ship.Vel, g.c, dt)
-		g.explosion.Update(g.ship.Vel, g.c, dt)
-		return nil
-	}
+// startGame starts a new run, playing back the replay if one is set
+func (g *Game) startGame() {
+	log.Debug("starting new game")
 
-	// Terminate program if escape key is pressed
-	if ebiten.IsKeyPressed(ebiten.KeyEscape) {
-		log.Debug("received escape key")
-		g.endGame()
+	// Use the seed, config and scenario of the replay if one is being played back
+	seed, cfg, sc := g.seeds.Int63(), g.cfg, g.scenario
+	if g.playback != nil {
+		seed, cfg, sc = g.playback.Seed, g.playback.Config, g.playback.Scenario
 	}
 
-	// Initialise the thrust direction
-	thrust := Vector{0, 0}
-
-	// If the ship is thrusting add a force and enable thrusting flag
-	if ebiten.IsKeyPressed(ebiten.KeyW) {
-		thrust.X = math.Sin(g.ship.AngPos)
-		thrust.Y = -math.Cos(g.ship.AngPos)
-		g.thrusting = true
-	} else if g.thrusting {
-		g.thrusting = false
+	if sc != nil {
+		g.world = sim.NewScenarioWorld(seed, cfg, sc)
+	} else {
+		g.world = sim.NewWorld(seed, cfg)
 	}
 
-	// Rotate the ship if A or D keys are pressed
-	if ebiten.IsKeyPressed(ebiten.KeyA) {
-		g.ship.AngPos -= 0.05
-	} else if ebiten.IsKeyPressed(ebiten.KeyD) {
-		g.ship.AngPos += 0.05
-	}
+	g.shaderSeed = shaderSeed(g.world.Seed)
 
-	// If space is pressed shoot a bullet
-	if inpututil.IsKeyJustPressed(ebiten.KeySpace) && g.ammo > 0 {
-		g.bullets.Activate(
-			g.ship.Pos,
-			g.ship.Rap.Add(Vector{
-				X: 3 * math.Sin(g.ship.AngPos),
-				Y: 3 * -math.Cos(g.ship.AngPos),
-			}),
-			0, 1,
-			1, 1,
-			0,
-		)
-
-		g.ammo--
+	if g.recordPath != "" {
+		g.recording = sim.NewReplay(seed, cfg, sc)
 	}
 
-	//Update the ship
-	g.ship.Update(
-		thrust.Scl(10).Add(getDrag(g.ship.Vel, 0.1)),
-		g.ship.Vel,
-		g.c,
-		dt,
-	)
+	g.accumulator = 0
+	g.alpha = 0
+	g.timeScale = 1
 
-	if len(g.asteroids.Collisions(g.ship, g.ship.Vel)) > 0 && !g.invincibility {
-		log.Debug("ship hit an asteroid")
+	g.Switch(&playScene{})
+}
 
-		g.invincibility = true
-		g.invincibilityStart = time.Now()
-		g.health--
+// startSandbox starts a new sandbox, which is never recorded
+func (g *Game) startSandbox() {
+	log.Debug("starting sandbox")
 
-		g.ship.AngVel += float64(rand.Int63n(2)*2-1) * 10
-	}
+	g.world = sim.NewSandbox(g.seeds.Int63(), g.cfg)
+	g.shaderSeed = shaderSeed(g.world.Seed)
 
-	if time.Since(g.invincibilityStart) > g.invincibilityDuration && g.invincibility {
-		log.Debug("invincibility period ended")
-		g.invincibility = false
-		g.ship.AngVel = 0
-	}
+	g.accumulator = 0
+	g.alpha = 0
+	g.timeScale = 1
 
-	if g.health <= 0 || g.ammo <= 0 {
-		log.Debug("game over", "health", g.health, "ammo", g.ammo)
-		explode(g.explosion, g.ship)
-		g.endGame()
-	}
+	g.Switch(&sandboxScene{})
+}
 
-	// Update the asteroids and solve for collisions with the ship
-	g.asteroids.UpdateWith(g.ship.Vel, g.c, dt, g.ship)
-
-	// Update the bullets
-	g.bullets.Update(g.ship.Vel, g.c, dt)
-
-	// Update the explosion particles
-	g.explosion.Update(g.ship.Vel, g.c, dt)
-
-	// Get all collisions between bullets and asteroids and for each:
-	for _, ints := range g.bullets.PoolCollisions(g.asteroids, g.ship.Vel) {
-		log.Debug("bullet hit asteroid", "bullet", ints[0], "asteroid", ints[1])
-		g.bullets.Deactivate(ints[0]) // Remove the bullet from the game
-		// If the asteroid is a big asteroid, add three small asteroids in its place
-		if g.asteroids.sprites[ints[1]] == 0 {
-			log.Debug("big asteroid hit, adding small asteroids")
-			parent := g.asteroids.particles[ints[1]]
-
-			for i := 0; i < 3; i++ {
-				radius := rand.Float64()*0.5 + 0.5
-				mass := math.Pi * math.Pow(radius, 2)
-
-				g.asteroids.Activate(
-					parent.Pos.Add(Vector{1, 0}.Rotate(float64(i)*math.Pi*2.0/3.0).Scl(0.2)),
-					parent.Rap.Add(Vector{1, 0}.Rotate(float64(i)*math.Pi*2.0/3.0).Scl(0.1)),
-					rand.Float64()*math.Pi*2,
-					rand.Float64()*0.25-0.125,
-					mass, radius,
-					1,
-				)
-			}
-
-			g.score += 3
-			g.ammo += 3
-		} else {
-			g.score += 1
-			g.ammo += 1
-		}
+// endGame moves to the game over screen and checks if the score is higher than the high score
+func (g *Game) endGame() {
+	log.Debug("moving to game over screen")
 
-		explode(g.explosion, g.asteroids.particles[ints[1]])
-		g.asteroids.Deactivate(ints[1]) // Remove the bigAsteroid from the game 		// Increment the score by 1
-		g.beginCLerp(g.c/8 + 10.0)      // Begin reducing the speed of light
-	}
+	// Scenarios aren't comparable with normal runs, so they have no high score
+	scored := g.world.Scenario == nil
 
-	if g.cLerp {
-		g.c = mapRange(time.Since(g.cLerpStart).Seconds(), 0, g.cLerpTime.Seconds(), g.cLerpInitial, g.cLerpTarget)
+	scene := &gameOverScene{
+		newHighScore: scored && g.world.Score > g.leaderboard.Best(),
 
-		if time.Since(g.cLerpStart).Seconds() > g.cLerpTime.Seconds() {
-			g.c = g.cLerpTarget
-			g.cLerp = false
-		}
+		// Replays that are played back don't go on the leaderboard
+		qualifies: scored && g.playback == nil && g.leaderboard.Qualifies(g.world.Score),
 	}
 
-	g.clock += dt * Gamma(g.ship.Vel.Mag(), g.c)
-
-	return nil
-}
-
-// mainMenuUpdate is called every physics update whenever the main menu is being displayed
-func (g *Game) mainMenuUpdate() error {
-	if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
-		log.Debug("leaving main menu")
-		g.startGame()
+	if scene.newHighScore {
+		log.Debug("new high score", "score", g.world.Score, "highScore", g.leaderboard.Best())
 	}
 
-	g.bgScroll -= dt * 10
-
-	return nil
-}
+	// Save the replay of the run
+	if g.recording != nil {
+		if err := g.recording.Save(g.recordPath); err != nil {
+			log.Error("failed to save replay", "path", g.recordPath, "error", err)
+		}
 
-// gameOverUpdate is called every physics update whenever the game over screen is being displayed
-func (g *Game) gameOverUpdate() error {
-	if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
-		log.Debug("moving to main menu")
-		g.gameOver = false
-		g.mainMenu = true
-		g.screenStart = time.Now()
+		g.recording = nil
 	}
 
-	g.bgScroll -= dt * 10
-
-	return nil
+	g.playback = nil
+	g.Switch(scene)
 }
 
-// Update is called every physics update
+// Update is called every frame, the world is stepped at its own fixed rate by fixedUpdate
 func (g *Game) Update() error {
-	if g.mainMenu {
-		return g.mainMenuUpdate()
-	} else if g.gameOver {
-		return g.gameOverUpdate()
-	} else {
-		return g.gameUpdate()
-	}
-}
-
-// gameDraw is called every frame when the game is being played
-func (g *Game) gameDraw(screen *ebiten.Image) {
-	// Draw the starfield background with a rectangle shader
-	screen.DrawRectShader(g.screenWidth, g.screenHeight, starfield, &ebiten.DrawRectShaderOptions{
-		Uniforms: map[string]any{
-			"C":             g.c,                    // Pass in the speed of light
-			"RedshiftRed":   redshiftData[0],        // Pass in redshift color data for the red channel
-			"RedshiftGreen": redshiftData[1],        // Pass in redshift color data for the green channel
-			"RedshiftBlue":  redshiftData[2],        // Pass in redshift color data for the blue channel
-			"Position":      g.ship.Pos.ToUniform(), // Pass in the position of the ship
-			"Velocity":      g.ship.Vel.ToUniform(), // Pass in the velocity of the ship
-			"Seed":          shaderSeed,             // Pass in the seed
-		},
-	})
-
-	// Initialise the color scale as nil
-	var colorScale *ebiten.ColorScale
-
-	// If the ship is invincible adjust the color scale to show this effect
-	if g.invincibility {
-		colorScale = &ebiten.ColorScale{}
-		colorScale.ScaleWithColor(colorMix(colorDamage, color.White, math.Abs(math.Sin(time.Since(g.invincibilityStart).Seconds()*4*math.Pi))))
-	}
-
-	// Draw the ship, if thrusting, use alt texture
-	if g.thrusting && !g.gameEnd {
-		g.ship.Draw(screen, shipThrust, 64, g.ship.Pos, g.ship.Vel, colorScale)
-	} else if !g.gameEnd {
-		g.ship.Draw(screen, ship, 64, g.ship.Pos, g.ship.Vel, colorScale)
+	now := time.Now()
+	if !g.lastUpdate.IsZero() {
+		g.delta = math.Min(now.Sub(g.lastUpdate).Seconds(), maxFrameTime)
 	}
 
-	// Draw the asteroids
-	g.asteroids.Draw(screen, g.ship.Pos, g.ship.Vel)
-
-	// Draw the bullets
-	g.bullets.Draw(screen, g.ship.Pos, g.ship.Vel)
-
-	// Draw the explosion particles
-	g.explosion.Draw(screen, g.ship.Pos, g.ship.Vel)
-
-	// Draw arrows to the closest bigAsteroid
-	if !g.gameEnd {
-		closestPos := g.asteroids.Closest(g.ship.Pos)
-		drawArrow(screen, g.ship.Pos, closestPos)
-	}
+	g.lastUpdate = now
 
-	// Draw the health of the ship, score and the speed of light
-	text.Draw(screen, fmt.Sprintf("♥ %d", g.health), guiFont, 10, 24, colorHealth)
-	text.Draw(screen, fmt.Sprintf("! %d", g.ammo), guiFont, 10, 48, colorDefault)
-	text.Draw(screen, fmt.Sprintf("SCORE: %04d", g.score), guiFont, 610, 24, colorDefault)
-	text.Draw(screen, fmt.Sprintf("v = %fc\nc = %sm/s", g.ship.Vel.Mag()/g.c, scientificNotation(g.c)), guiFont, 10, 572, colorDefault)
+	return g.Scene().Update(g)
 }
 
-// mainMenuDraw is called every frame when the main menu is being displayed
-func (g *Game) mainMenuDraw(screen *ebiten.Image) {
-	screen.DrawRectShader(g.screenWidth, g.screenHeight, starfield, &ebiten.DrawRectShaderOptions{
-		Uniforms: map[string]any{
-			"C":             g.c,                               // Pass in the speed of light
-			"RedshiftRed":   redshiftData[0],                   // Pass in redshift color data for the red channel
-			"RedshiftGreen": redshiftData[1],                   // Pass in redshift color data for the green channel
-			"RedshiftBlue":  redshiftData[2],                   // Pass in redshift color data for the blue channel
-			"Position":      Vector{0, g.bgScroll}.ToUniform(), // Pass in the position of the ship
-			"Velocity":      Vector{}.ToUniform(),              // Pass in the velocity of the ship
-			"Seed":          shaderSeed + 10000,                // Pass in the seed
-		},
-	})
+// fixedUpdate calls step once for every tick of the world that has passed in real time since the previous update,
+// and sets how far between ticks the world is drawn
+func (g *Game) fixedUpdate(step func()) {
+	g.accumulator += g.delta * g.timeScale
 
-	if time.Since(g.screenStart).Seconds() > 1.0 {
-		text.Draw(screen, "RELATIVISTIC ASTEROIDS", guiFont, 230, 24, colorTitle)
+	for g.accumulator >= g.world.Dt {
+		step()
+		g.accumulator -= g.world.Dt
 	}
 
-	if time.Since(g.screenStart).Seconds() > 2.0 {
-		text.Draw(screen, "Press space to start", guiFont, 250, 572, colorDefault)
-	}
+	g.alpha = g.accumulator / g.world.Dt
 }
 
-// gameOverDraw is called every frame when the game over screen is being displayed
-func (g *Game) gameOverDraw(screen *ebiten.Image) {
-	screen.DrawRectShader(g.screenWidth, g.screenHeight, starfield, &ebiten.DrawRectShaderOptions{
-		Uniforms: map[string]any{
-			"C":             g.c,                               // Pass in the speed of light
-			"RedshiftRed":   redshiftData[0],                   // Pass in redshift color data for the red channel
-			"RedshiftGreen": redshiftData[1],                   // Pass in redshift color data for the green channel
-			"RedshiftBlue":  redshiftData[2],                   // Pass in redshift color data for the blue channel
-			"Position":      Vector{0, g.bgScroll}.ToUniform(), // Pass in the position of the ship
-			"Velocity":      Vector{}.ToUniform(),              // Pass in the velocity of the ship
-			"Seed":          shaderSeed + 10000,                // Pass in the seed
-		},
-	})
-
-	if time.Since(g.screenStart).Seconds() > 1.0 {
-		if g.newHighScore {
-			text.Draw(screen, "NEW HIGH SCORE", guiFont, 300, 24, colorSuccess)
-		} else {
-			text.Draw(screen, "GAME OVER", guiFont, 320, 24, colorFailure)
-		}
-	}
-
-	if time.Since(g.screenStart).Seconds() > 1.5 {
-		text.Draw(screen, fmt.Sprintf("  SCORE: %04d", g.score), guiFont, 280, 100, colorDefault)
-	}
-
-	if time.Since(g.screenStart).Seconds() > 2.0 {
-		text.Draw(screen, fmt.Sprintf("HISCORE: %04d", g.highScore), guiFont, 280, 124, colorDefault)
-	}
-
-	if time.Since(g.screenStart).Seconds() > 3 {
-		text.Draw(screen, "Press space to continue", guiFont, 230, 572, colorDefault)
+// Draw is called every frame, drawing every scene on the stack from the bottom up
+func (g *Game) Draw(screen *ebiten.Image) {
+	for _, s := range g.scenes {
+		s.Draw(g, screen)
 	}
 }
 
-// Draw is called every frame
-func (g *Game) Draw(screen *ebiten.Image) {
-	if g.mainMenu {
-		g.mainMenuDraw(screen)
-	} else if g.gameOver {
-		g.gameOverDraw(screen)
-	} else {
-		g.gameDraw(screen)
-	}
+// shaderSeed returns the seed of the starfield shader for a world created with the given seed
+func shaderSeed(seed int64) float64 {
+	return rand.New(rand.NewSource(seed)).Float64()*10000 - 5000
 }
 
 // Layout is called every time the window is resized
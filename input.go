@@ -0,0 +1,421 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/charmbracelet/log"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"rp2/sim"
+	"slices"
+	"strings"
+)
+
+// Action is something the player can do in a run, which can be bound to keys and gamepad buttons
+type Action int
+
+// Defining the actions.
+const (
+	ActionThrust Action = iota
+	ActionRotateLeft
+	ActionRotateRight
+	ActionFire
+	ActionPause
+
+	// The number of actions
+	actionCount
+)
+
+// actionNames are the names of the actions, used in the bindings file and the settings screen
+var actionNames = [actionCount]string{
+	ActionThrust:      "thrust",
+	ActionRotateLeft:  "rotateLeft",
+	ActionRotateRight: "rotateRight",
+	ActionFire:        "fire",
+	ActionPause:       "pause",
+}
+
+// String returns the name of the action
+func (a Action) String() string {
+	if a < 0 || a >= actionCount {
+		return fmt.Sprintf("Action(%d)", int(a))
+	}
+
+	return actionNames[a]
+}
+
+// MarshalText returns the name of the action
+func (a Action) MarshalText() ([]byte, error) {
+	if a < 0 || a >= actionCount {
+		return nil, fmt.Errorf("unknown action %d", int(a))
+	}
+
+	return []byte(actionNames[a]), nil
+}
+
+// UnmarshalText parses the name of an action
+func (a *Action) UnmarshalText(text []byte) error {
+	for i, name := range actionNames {
+		if name == string(text) {
+			*a = Action(i)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown action %q", text)
+}
+
+// GamepadButton is a button of a gamepad with the standard layout, stored in the bindings file by its name on an Xbox
+// controller
+type GamepadButton ebiten.StandardGamepadButton
+
+// gamepadButtonNames are the names of the standard gamepad buttons
+var gamepadButtonNames = map[GamepadButton]string{
+	GamepadButton(ebiten.StandardGamepadButtonRightBottom):      "A",
+	GamepadButton(ebiten.StandardGamepadButtonRightRight):       "B",
+	GamepadButton(ebiten.StandardGamepadButtonRightLeft):        "X",
+	GamepadButton(ebiten.StandardGamepadButtonRightTop):         "Y",
+	GamepadButton(ebiten.StandardGamepadButtonFrontTopLeft):     "LB",
+	GamepadButton(ebiten.StandardGamepadButtonFrontTopRight):    "RB",
+	GamepadButton(ebiten.StandardGamepadButtonFrontBottomLeft):  "LT",
+	GamepadButton(ebiten.StandardGamepadButtonFrontBottomRight): "RT",
+	GamepadButton(ebiten.StandardGamepadButtonCenterLeft):       "Back",
+	GamepadButton(ebiten.StandardGamepadButtonCenterRight):      "Start",
+	GamepadButton(ebiten.StandardGamepadButtonLeftStick):        "LS",
+	GamepadButton(ebiten.StandardGamepadButtonRightStick):       "RS",
+	GamepadButton(ebiten.StandardGamepadButtonLeftTop):          "Up",
+	GamepadButton(ebiten.StandardGamepadButtonLeftBottom):       "Down",
+	GamepadButton(ebiten.StandardGamepadButtonLeftLeft):         "Left",
+	GamepadButton(ebiten.StandardGamepadButtonLeftRight):        "Right",
+	GamepadButton(ebiten.StandardGamepadButtonCenterCenter):     "Home",
+}
+
+// String returns the name of the button
+func (b GamepadButton) String() string {
+	if name, ok := gamepadButtonNames[b]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("Button(%d)", int(b))
+}
+
+// MarshalText returns the name of the button
+func (b GamepadButton) MarshalText() ([]byte, error) {
+	name, ok := gamepadButtonNames[b]
+	if !ok {
+		return nil, fmt.Errorf("unknown gamepad button %d", int(b))
+	}
+
+	return []byte(name), nil
+}
+
+// UnmarshalText parses the name of a button
+func (b *GamepadButton) UnmarshalText(text []byte) error {
+	for button, name := range gamepadButtonNames {
+		if strings.EqualFold(name, string(text)) {
+			*b = button
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown gamepad button %q", text)
+}
+
+// Binding is the keys and gamepad buttons bound to an action
+type Binding struct {
+	Keys    []ebiten.Key    `json:"keys"`
+	Buttons []GamepadButton `json:"buttons"`
+}
+
+// String returns the keys and buttons of the binding, for showing on the settings screen
+func (b *Binding) String() string {
+	names := make([]string, 0, len(b.Keys)+len(b.Buttons))
+
+	for _, k := range b.Keys {
+		names = append(names, k.String())
+	}
+
+	for _, button := range b.Buttons {
+		names = append(names, "Pad "+button.String())
+	}
+
+	if len(names) == 0 {
+		return "-"
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// Controls maps the player's keyboard and gamepads to actions
+type Controls struct {
+	Bindings map[Action]*Binding `json:"bindings"` // The keys and buttons bound to each action
+	Deadzone float64             `json:"deadzone"` // How far the left stick must be pushed before the ship rotates
+
+	path string // Where the controls are saved, empty if they are not saved
+}
+
+// DefaultControls returns the default controls
+func DefaultControls() *Controls {
+	return &Controls{
+		Bindings: map[Action]*Binding{
+			ActionThrust: {
+				Keys:    []ebiten.Key{ebiten.KeyW},
+				Buttons: []GamepadButton{GamepadButton(ebiten.StandardGamepadButtonFrontBottomRight)},
+			},
+			ActionRotateLeft: {
+				Keys:    []ebiten.Key{ebiten.KeyA},
+				Buttons: []GamepadButton{GamepadButton(ebiten.StandardGamepadButtonLeftLeft)},
+			},
+			ActionRotateRight: {
+				Keys:    []ebiten.Key{ebiten.KeyD},
+				Buttons: []GamepadButton{GamepadButton(ebiten.StandardGamepadButtonLeftRight)},
+			},
+			ActionFire: {
+				Keys:    []ebiten.Key{ebiten.KeySpace},
+				Buttons: []GamepadButton{GamepadButton(ebiten.StandardGamepadButtonRightBottom)},
+			},
+			ActionPause: {
+				Keys:    []ebiten.Key{ebiten.KeyEscape},
+				Buttons: []GamepadButton{GamepadButton(ebiten.StandardGamepadButtonCenterRight)},
+			},
+		},
+		Deadzone: 0.2,
+	}
+}
+
+// LoadControls reads the controls from the file at path. A missing or corrupted file gives the default controls, and
+// any actions missing from the file keep their default bindings.
+func LoadControls(path string) *Controls {
+	log.Debug("loading controls", "path", path)
+
+	c := DefaultControls()
+	c.path = path
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Debug("no controls file, using the default controls")
+		return c
+	} else if err != nil {
+		log.Warn("failed to read controls, using the default controls", "path", path, "error", err)
+		return c
+	}
+
+	loaded := DefaultControls()
+	if err := json.Unmarshal(data, loaded); err != nil {
+		log.Warn("controls are corrupted, using the default controls", "path", path, "error", err)
+		return c
+	}
+
+	// Actions bound to null keep their default bindings, and reserved keys are dropped
+	for a, b := range loaded.Bindings {
+		if b == nil {
+			loaded.Bindings[a] = c.Bindings[a]
+			continue
+		}
+
+		b.Keys = slices.DeleteFunc(b.Keys, func(k ebiten.Key) bool {
+			if reservedKey(k) {
+				log.Warn("key is reserved, unbinding it", "action", a, "key", k)
+				return true
+			}
+
+			return false
+		})
+	}
+
+	if loaded.Deadzone < 0 || loaded.Deadzone >= 1 {
+		log.Warn("invalid gamepad deadzone, using the default", "deadzone", loaded.Deadzone)
+		loaded.Deadzone = c.Deadzone
+	}
+
+	loaded.path = path
+
+	return loaded
+}
+
+// Save writes the controls to their file, creating the directory if needed
+func (c *Controls) Save() error {
+	if c.path == "" {
+		return nil
+	}
+
+	log.Debug("saving controls", "path", c.path)
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(c.path, data, 0o644)
+}
+
+// Reset restores the default binding of an action
+func (c *Controls) Reset(a Action) {
+	c.Bindings[a] = DefaultControls().Bindings[a]
+}
+
+// gamepads returns the connected gamepads that have the standard layout
+func gamepads() []ebiten.GamepadID {
+	ids := ebiten.AppendGamepadIDs(nil)
+	out := ids[:0]
+
+	for _, id := range ids {
+		if ebiten.IsStandardGamepadLayoutAvailable(id) {
+			out = append(out, id)
+		}
+	}
+
+	return out
+}
+
+// Value returns how strongly an action is pressed, from 0 to 1. Keys are either fully pressed or not, while analogue
+// buttons such as triggers give values in between.
+func (c *Controls) Value(a Action) float64 {
+	b := c.Bindings[a]
+	out := 0.0
+
+	for _, k := range b.Keys {
+		if ebiten.IsKeyPressed(k) {
+			return 1
+		}
+	}
+
+	for _, id := range gamepads() {
+		for _, button := range b.Buttons {
+			out = math.Max(out, ebiten.StandardGamepadButtonValue(id, ebiten.StandardGamepadButton(button)))
+		}
+	}
+
+	return out
+}
+
+// JustPressed returns whether an action was pressed this update
+func (c *Controls) JustPressed(a Action) bool {
+	b := c.Bindings[a]
+
+	for _, k := range b.Keys {
+		if inpututil.IsKeyJustPressed(k) {
+			return true
+		}
+	}
+
+	for _, id := range gamepads() {
+		for _, button := range b.Buttons {
+			if inpututil.IsStandardGamepadButtonJustPressed(id, ebiten.StandardGamepadButton(button)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Input reads the player's input for a step of the world. The left stick of a gamepad rotates the ship in proportion
+// to how far it is pushed, overriding the rotate actions when it is outside the deadzone.
+func (c *Controls) Input() sim.Input {
+	in := sim.Input{
+		Thrust: c.Value(ActionThrust),
+		Rotate: c.Value(ActionRotateRight) - c.Value(ActionRotateLeft),
+		Fire:   c.JustPressed(ActionFire),
+	}
+
+	for _, id := range gamepads() {
+		x := ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickHorizontal)
+		if math.Abs(x) > c.Deadzone {
+			// Rescale so that the rotation starts from 0 at the edge of the deadzone
+			in.Rotate = math.Copysign((math.Abs(x)-c.Deadzone)/(1-c.Deadzone), x)
+		}
+	}
+
+	return in
+}
+
+// Defining the fixed menu controls, which can't be rebound so that the menus are always usable.
+var (
+	menuUpKeys         = []ebiten.Key{ebiten.KeyArrowUp, ebiten.KeyW}
+	menuDownKeys       = []ebiten.Key{ebiten.KeyArrowDown, ebiten.KeyS}
+	menuConfirmKeys    = []ebiten.Key{ebiten.KeyEnter, ebiten.KeySpace}
+	menuBackKeys       = []ebiten.Key{ebiten.KeyEscape}
+	menuUpButtons      = []ebiten.StandardGamepadButton{ebiten.StandardGamepadButtonLeftTop}
+	menuDownButtons    = []ebiten.StandardGamepadButton{ebiten.StandardGamepadButtonLeftBottom}
+	menuConfirmButtons = []ebiten.StandardGamepadButton{ebiten.StandardGamepadButtonRightBottom, ebiten.StandardGamepadButtonCenterRight}
+	menuBackButtons    = []ebiten.StandardGamepadButton{ebiten.StandardGamepadButtonRightRight}
+)
+
+// Defining the fixed view and sandbox keys, which can't be rebound so that pressing one never also does an action.
+const (
+	viewRetardedKey     = ebiten.KeyR
+	viewSimultaneousKey = ebiten.KeyH
+	viewDopplerKey      = ebiten.KeyF
+	viewAberrationKey   = ebiten.KeyB
+	viewClocksKey       = ebiten.KeyT
+	viewDiagramKey      = ebiten.KeyM
+	sandboxResetKey     = ebiten.Key0
+	sandboxPauseKey     = ebiten.KeyP
+	sandboxClearKey     = ebiten.KeyBackspace
+)
+
+// reservedKey returns whether a key is fixed to a view or the sandbox, and so can't be bound to an action
+func reservedKey(k ebiten.Key) bool {
+	switch k {
+	case viewRetardedKey, viewSimultaneousKey, viewDopplerKey, viewAberrationKey, viewClocksKey, viewDiagramKey,
+		sandboxResetKey, sandboxPauseKey, sandboxClearKey:
+		return true
+	}
+
+	for _, setting := range sandboxSettings {
+		if k == setting.down || k == setting.up {
+			return true
+		}
+	}
+
+	return false
+}
+
+// anyJustPressed returns whether any of the keys, or any of the buttons on any gamepad, were pressed this update
+func anyJustPressed(keys []ebiten.Key, buttons []ebiten.StandardGamepadButton) bool {
+	for _, k := range keys {
+		if inpututil.IsKeyJustPressed(k) {
+			return true
+		}
+	}
+
+	for _, id := range gamepads() {
+		for _, button := range buttons {
+			if inpututil.IsStandardGamepadButtonJustPressed(id, button) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// menuUp returns whether the selection in a menu should move up
+func menuUp() bool {
+	return anyJustPressed(menuUpKeys, menuUpButtons)
+}
+
+// menuDown returns whether the selection in a menu should move down
+func menuDown() bool {
+	return anyJustPressed(menuDownKeys, menuDownButtons)
+}
+
+// menuConfirm returns whether the selected menu option should be chosen
+func menuConfirm() bool {
+	return anyJustPressed(menuConfirmKeys, menuConfirmButtons)
+}
+
+// menuBack returns whether the menu should be left
+func menuBack() bool {
+	return anyJustPressed(menuBackKeys, menuBackButtons)
+}
@@ -1,19 +1,64 @@
 package main
 
 import (
+	"flag"
 	"github.com/charmbracelet/log"
 	"github.com/hajimehoshi/ebiten/v2"
+	"os"
+	"rp2/sim"
+	"time"
 )
 
 func main() {
+	// Parse the command line flags
+	record := flag.String("record", "", "record each run to a replay file")
+	replay := flag.String("replay", "", "play back a replay file")
+	scenario := flag.String("scenario", "", "set up every run from a scenario file")
+	cfg, err := sim.ParseConfig(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatal("invalid config", "error", err)
+	}
+
+	// The settings of the scenario override the config
+	var sc *sim.Scenario
+	if *scenario != "" {
+		if sc, err = sim.LoadScenario(*scenario); err != nil {
+			log.Fatal("failed to load scenario", "path", *scenario, "error", err)
+		}
+
+		if cfg, err = sc.Apply(cfg); err != nil {
+			log.Fatal("invalid scenario settings", "path", *scenario, "error", err)
+		}
+	}
+
 	// Create a new Game object
 	g := new(Game)
-	g.Init(800, 600)
+	g.Init(cfg, time.Now().UnixNano())
+
+	if sc != nil {
+		g.PlayScenario(sc)
+	}
+
+	if *record != "" {
+		g.RecordTo(*record)
+	}
+
+	if *replay != "" {
+		r, err := sim.LoadReplay(*replay)
+		if err != nil {
+			log.Fatal("failed to load replay", "path", *replay, "error", err)
+		}
+
+		g.Play(r)
+	}
 
 	// Initialise the window
 	ebiten.SetWindowTitle("Relativistic Asteroids")
 	ebiten.SetWindowSize(g.screenWidth, g.screenHeight)
 
+	// Update once per frame, the world is stepped at its own rate
+	ebiten.SetTPS(ebiten.SyncWithFPS)
+
 	log.Debug("Starting the game loop")
 
 	// Run the game
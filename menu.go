@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+	"github.com/charmbracelet/log"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"rp2/sim"
+	"time"
+)
+
+// mainMenuScene is the title screen shown when the game starts
+type mainMenuScene struct {
+	screenTimer
+}
+
+// Update starts a run or the sandbox, or opens the leaderboard or settings
+func (s *mainMenuScene) Update(g *Game) error {
+	if menuConfirm() {
+		log.Debug("leaving main menu")
+		g.startGame()
+	} else if anyJustPressed([]ebiten.Key{ebiten.KeyL}, []ebiten.StandardGamepadButton{ebiten.StandardGamepadButtonRightTop}) {
+		log.Debug("moving to leaderboard")
+		g.Switch(&leaderboardScene{rank: -1})
+	} else if anyJustPressed([]ebiten.Key{ebiten.KeyC}, []ebiten.StandardGamepadButton{ebiten.StandardGamepadButtonCenterLeft}) {
+		log.Debug("moving to controls")
+		g.Switch(&controlsScene{})
+	} else if anyJustPressed([]ebiten.Key{ebiten.KeyX}, []ebiten.StandardGamepadButton{ebiten.StandardGamepadButtonRightLeft}) {
+		log.Debug("moving to sandbox")
+		g.startSandbox()
+	}
+
+	g.bgScroll -= g.delta * 10
+
+	return nil
+}
+
+// Draw draws the title and instructions
+func (s *mainMenuScene) Draw(g *Game, screen *ebiten.Image) {
+	g.menuBackgroundDraw(screen)
+
+	if s.elapsed() > 1.0 {
+		drawCentered(screen, "RELATIVISTIC ASTEROIDS", 24, colorTitle)
+	}
+
+	if s.elapsed() > 2.0 {
+		drawCentered(screen, "Press space to start", g.screenHeight-100, colorDefault)
+		drawCentered(screen, "Press X for the sandbox", g.screenHeight-76, colorDefault)
+		drawCentered(screen, "Press L for the leaderboard", g.screenHeight-52, colorDefault)
+		drawCentered(screen, "Press C for the controls", g.screenHeight-28, colorDefault)
+	}
+}
+
+// gameOverScene shows the score of the run that just ended
+type gameOverScene struct {
+	screenTimer
+
+	newHighScore bool // Whether the run beat the best score on the leaderboard
+	qualifies    bool // Whether the run made it onto the leaderboard
+}
+
+// Update moves on to the name entry screen if the run made it onto the leaderboard, otherwise the main menu
+func (s *gameOverScene) Update(g *Game) error {
+	if menuConfirm() {
+		if s.qualifies {
+			log.Debug("moving to name entry")
+			g.Switch(&nameEntryScene{})
+		} else {
+			log.Debug("moving to main menu")
+			g.Switch(&mainMenuScene{})
+		}
+	}
+
+	g.bgScroll -= g.delta * 10
+
+	return nil
+}
+
+// Draw draws the score and high score
+func (s *gameOverScene) Draw(g *Game, screen *ebiten.Image) {
+	g.menuBackgroundDraw(screen)
+
+	if s.elapsed() > 1.0 {
+		if g.world.Scenario != nil && g.world.Won {
+			drawCentered(screen, "SCENARIO COMPLETE", 24, colorSuccess)
+		} else if g.world.Scenario != nil {
+			drawCentered(screen, "SCENARIO FAILED", 24, colorFailure)
+		} else if s.newHighScore {
+			drawCentered(screen, "NEW HIGH SCORE", 24, colorSuccess)
+		} else {
+			drawCentered(screen, "GAME OVER", 24, colorFailure)
+		}
+	}
+
+	if s.elapsed() > 1.5 {
+		drawCentered(screen, fmt.Sprintf("  SCORE: %04d", g.world.Score), 100, colorDefault)
+	}
+
+	if s.elapsed() > 2.0 {
+		drawCentered(screen, fmt.Sprintf("HISCORE: %04d", max(g.leaderboard.Best(), g.world.Score)), 124, colorDefault)
+	}
+
+	if s.elapsed() > 2.5 && g.world.Waves != nil {
+		drawCentered(screen, fmt.Sprintf("   WAVE: %04d", g.world.Waves.Number), 148, colorDefault)
+	}
+
+	if s.elapsed() > 3 {
+		drawCentered(screen, "Press space to continue", g.screenHeight-28, colorDefault)
+	}
+}
+
+// nameEntryScene asks the player for their name to put on the leaderboard
+type nameEntryScene struct {
+	screenTimer
+
+	name []rune // The name entered so far
+}
+
+// Update adds typed characters to the name, and adds the run to the leaderboard once enter is pressed
+func (s *nameEntryScene) Update(g *Game) error {
+	// Add the typed characters to the name
+	for _, r := range ebiten.AppendInputChars(nil) {
+		if len(s.name) < sim.MaxNameLength && r >= ' ' && r <= '~' {
+			s.name = append(s.name, r)
+		}
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) && len(s.name) > 0 {
+		s.name = s.name[:len(s.name)-1]
+	}
+
+	// Space is typed into the name, so only enter and the gamepad confirm the name
+	if anyJustPressed([]ebiten.Key{ebiten.KeyEnter}, menuConfirmButtons) {
+		name := string(s.name)
+		if name == "" {
+			name = "ANONYMOUS"
+		}
+
+		rank := g.leaderboard.Add(sim.LeaderboardEntry{
+			Name:     name,
+			Score:    g.world.Score,
+			Date:     time.Now(),
+			C:        g.world.C,
+			MaxGamma: g.world.MaxGamma,
+		})
+
+		if err := g.leaderboard.Save(); err != nil {
+			log.Error("failed to save leaderboard", "error", err)
+		}
+
+		log.Debug("moving to leaderboard", "rank", rank)
+		g.Switch(&leaderboardScene{rank: rank})
+	}
+
+	g.bgScroll -= g.delta * 10
+
+	return nil
+}
+
+// Draw draws the name being entered
+func (s *nameEntryScene) Draw(g *Game, screen *ebiten.Image) {
+	g.menuBackgroundDraw(screen)
+
+	drawCentered(screen, "ENTER YOUR NAME", 24, colorTitle)
+	drawCentered(screen, fmt.Sprintf("SCORE: %04d", g.world.Score), 100, colorDefault)
+
+	// Blink a cursor after the name
+	cursor := " "
+	if int(s.elapsed()*2)%2 == 0 {
+		cursor = "_"
+	}
+
+	drawCentered(screen, string(s.name)+cursor, g.screenHeight/2, colorSuccess)
+	drawCentered(screen, "Press enter to confirm", g.screenHeight-28, colorDefault)
+}
+
+// leaderboardScene shows the best runs
+type leaderboardScene struct {
+	screenTimer
+
+	rank int // The rank of the entry to highlight, -1 if none is highlighted
+}
+
+// Update returns to the main menu
+func (s *leaderboardScene) Update(g *Game) error {
+	if menuConfirm() || menuBack() {
+		log.Debug("moving to main menu")
+		g.Switch(&mainMenuScene{})
+	}
+
+	g.bgScroll -= g.delta * 10
+
+	return nil
+}
+
+// Draw draws the entries of the leaderboard
+func (s *leaderboardScene) Draw(g *Game, screen *ebiten.Image) {
+	g.menuBackgroundDraw(screen)
+
+	drawCentered(screen, "LEADERBOARD", 24, colorTitle)
+
+	if len(g.leaderboard.Entries) == 0 {
+		drawCentered(screen, "No scores yet", 100, colorDefault)
+	}
+
+	for i, e := range g.leaderboard.Entries {
+		clr := colorDefault
+		if i == s.rank {
+			clr = colorSuccess
+		}
+
+		// The row has to fit across the default window at the size of the font, so the date of the run isn't shown
+		line := fmt.Sprintf("%2d. %-12s %04d  %sm/s  γ = %.2f", i+1, e.Name, e.Score, scientificNotation(e.C), e.MaxGamma)
+		text.Draw(screen, line, guiFont, 10, 76+i*24, clr)
+	}
+
+	drawCentered(screen, "Press space to return", g.screenHeight-28, colorDefault)
+}
+
+// menuBackgroundDraw draws the slowly scrolling starfield behind the menu screens
+func (g *Game) menuBackgroundDraw(screen *ebiten.Image) {
+	screen.DrawRectShader(g.screenWidth, g.screenHeight, starfield, &ebiten.DrawRectShaderOptions{
+		Uniforms: map[string]any{
+			"C":             g.world.C,                             // Pass in the speed of light
+			"RedshiftRed":   redshiftData[0],                       // Pass in redshift color data for the red channel
+			"RedshiftGreen": redshiftData[1],                       // Pass in redshift color data for the green channel
+			"RedshiftBlue":  redshiftData[2],                       // Pass in redshift color data for the blue channel
+			"Position":      sim.Vector{Y: g.bgScroll}.ToUniform(), // Pass in the position of the ship
+			"Velocity":      sim.Vector{}.ToUniform(),              // Pass in the velocity of the ship
+			"Seed":          g.shaderSeed + 10000,                  // Pass in the seed
+			"Size":          screenSize(screen),                    // Pass in the size of the screen
+		},
+	})
+}
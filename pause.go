@@ -0,0 +1,95 @@
+package main
+
+import (
+	"github.com/charmbracelet/log"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"image/color"
+)
+
+// Defining the options of the pause menu.
+const (
+	pauseResume = iota
+	pauseRestart
+	pauseQuit
+)
+
+// pauseOptions are the labels of the pause menu options
+var pauseOptions = [...]string{
+	pauseResume:  "RESUME",
+	pauseRestart: "RESTART",
+	pauseQuit:    "QUIT",
+}
+
+// pauseScene is drawn over a run being played and freezes it. As every timer of the world runs on simulation time,
+// not stepping the world is enough to freeze the run.
+type pauseScene struct {
+	play     *playScene // The run that was paused
+	selected int        // The selected option
+}
+
+// Enter logs that the run was paused
+func (s *pauseScene) Enter(*Game) {
+	log.Debug("paused")
+}
+
+// Exit logs that the run was unpaused
+func (s *pauseScene) Exit(*Game) {
+	log.Debug("unpaused")
+}
+
+// Update moves the selection and applies the selected option
+func (s *pauseScene) Update(g *Game) error {
+	if g.controls.JustPressed(ActionPause) || menuBack() {
+		g.Pop()
+		return nil
+	}
+
+	// The view can still be changed while paused, to look at the frozen run
+	g.viewUpdate()
+
+	if menuUp() {
+		s.selected = (s.selected + len(pauseOptions) - 1) % len(pauseOptions)
+	}
+
+	if menuDown() {
+		s.selected = (s.selected + 1) % len(pauseOptions)
+	}
+
+	if menuConfirm() {
+		switch s.selected {
+		case pauseResume:
+			g.Pop()
+		case pauseRestart:
+			log.Debug("restarting run")
+			g.startGame()
+		case pauseQuit:
+			// The run is ended on the next step, so that the quit is recorded in the replay
+			g.Pop()
+			s.play.quit = true
+		}
+	}
+
+	return nil
+}
+
+// Draw dims the run behind it and draws the options, the spacetime diagram is redrawn on top to be explained
+func (s *pauseScene) Draw(g *Game, screen *ebiten.Image) {
+	vector.DrawFilledRect(screen, 0, 0, float32(g.screenWidth), float32(g.screenHeight), color.RGBA{A: 160}, false)
+
+	if g.diagram {
+		g.drawDiagram(screen)
+	}
+
+	drawCentered(screen, "PAUSED", 24, colorTitle)
+
+	for i, option := range pauseOptions {
+		clr := color.Color(colorDefault)
+		if i == s.selected {
+			clr = colorSuccess
+			option = "> " + option + " <"
+		}
+
+		drawCentered(screen, option, g.screenHeight/2-24+i*24, clr)
+	}
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"fmt"
+	"github.com/charmbracelet/log"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"image/color"
+	"math"
+	"rp2/sim"
+)
+
+// Defining how long titles are shown for.
+const (
+	// How many seconds the name and description of a scenario are shown for when it starts
+	scenarioTitleTime = 5
+
+	// How many seconds the number of a wave is shown for when it is spawned
+	waveTitleTime = 2
+)
+
+// playScene is the scene of a run being played
+type playScene struct {
+	quit bool // Whether the player quit the run from the pause menu
+	fire bool // Whether the player has fired since the previous step, as there may be no step in an update
+}
+
+// Enter does nothing, the run is started by Game.startGame
+func (s *playScene) Enter(*Game) {}
+
+// Exit does nothing
+func (s *playScene) Exit(*Game) {}
+
+// Update steps the world using the player's input, or the input of the replay being played back
+func (s *playScene) Update(g *Game) error {
+	if g.controls.JustPressed(ActionPause) {
+		g.Push(&pauseScene{play: s})
+		return nil
+	}
+
+	g.viewUpdate()
+
+	controls := g.controls.Input()
+	s.fire = s.fire || controls.Fire
+
+	g.fixedUpdate(func() {
+		// Take the input from the replay if one is being played back, the player can still quit it from the pause
+		// menu. The input is quantised as it would be in a replay, so that a recording of the run reproduces it exactly.
+		in := controls
+		in.Fire = s.fire
+		in.Escape = s.quit
+		in = in.Quantised()
+
+		if g.playback != nil {
+			in = g.playback.Playback(g.world.Tick, in)
+		}
+
+		if g.recording != nil {
+			g.recording.Record(in)
+		}
+
+		g.world.Step(in)
+		s.fire = false
+	})
+
+	// Once the run has ended, watch the ship explode
+	if g.world.Ended {
+		g.Switch(&dyingScene{})
+	}
+
+	return nil
+}
+
+// Draw draws the world
+func (s *playScene) Draw(g *Game, screen *ebiten.Image) {
+	g.worldDraw(screen)
+}
+
+// dyingScene is the scene after a run has ended, where the remaining particles are simulated for a few seconds
+// before moving to the game over screen
+type dyingScene struct{}
+
+// Enter does nothing
+func (s *dyingScene) Enter(*Game) {}
+
+// Exit does nothing
+func (s *dyingScene) Exit(*Game) {}
+
+// Update steps the world until 5 seconds have passed since the run ended
+func (s *dyingScene) Update(g *Game) error {
+	if g.world.Since(g.world.EndTick) > 5 {
+		g.endGame()
+		return nil
+	}
+
+	g.viewUpdate()
+	g.fixedUpdate(func() {
+		g.world.Step(sim.Input{})
+	})
+
+	return nil
+}
+
+// Draw draws the world
+func (s *dyingScene) Draw(g *Game, screen *ebiten.Image) {
+	g.worldDraw(screen)
+}
+
+// viewUpdate toggles how the world is drawn
+func (g *Game) viewUpdate() {
+	// Toggle drawing particles at their retarded positions
+	if inpututil.IsKeyJustPressed(viewRetardedKey) {
+		g.retarded = !g.retarded
+		log.Debug("toggled retarded view", "retarded", g.retarded)
+	}
+
+	// Toggle drawing particles on the ship's hyperplane of simultaneity
+	if inpututil.IsKeyJustPressed(viewSimultaneousKey) {
+		g.simultaneous = !g.simultaneous
+		log.Debug("toggled simultaneous view", "simultaneous", g.simultaneous)
+	}
+
+	// Toggle tinting particles by their doppler shift
+	if inpututil.IsKeyJustPressed(viewDopplerKey) {
+		g.doppler = !g.doppler
+		log.Debug("toggled doppler tint", "doppler", g.doppler)
+	}
+
+	// Toggle drawing particles at their aberrated positions
+	if inpututil.IsKeyJustPressed(viewAberrationKey) {
+		g.aberration = !g.aberration
+		log.Debug("toggled aberration", "aberration", g.aberration)
+	}
+
+	// Toggle drawing the clocks of the asteroids
+	if inpututil.IsKeyJustPressed(viewClocksKey) {
+		g.clocks = !g.clocks
+		log.Debug("toggled asteroid clocks", "clocks", g.clocks)
+	}
+
+	// Toggle drawing the spacetime diagram
+	if inpututil.IsKeyJustPressed(viewDiagramKey) {
+		g.diagram = !g.diagram
+		log.Debug("toggled spacetime diagram", "diagram", g.diagram)
+	}
+}
+
+// shipView returns the view of the world from the ship, between the previous and current tick
+func (g *Game) shipView() View {
+	w := g.world
+	shipPos, _ := w.Ship.Interpolate(g.alpha)
+
+	return View{
+		Pos:      shipPos,
+		Frame:    w.Frame(),
+		C:        w.C,
+		Time:     w.Time() + (g.alpha-1)*w.Dt,
+		Alpha:    g.alpha,
+		Retarded: g.retarded,
+
+		Simultaneous: g.simultaneous,
+
+		Doppler:    g.doppler,
+		Aberration: g.aberration,
+
+		Topology: w.Topology,
+	}
+}
+
+// worldDraw draws the world from the point of view of the ship
+func (g *Game) worldDraw(screen *ebiten.Image) {
+	w := g.world
+
+	// Interpolate the ship between the previous and current tick
+	shipPos, _ := w.Ship.Interpolate(g.alpha)
+
+	// The starfield moves as if the ship had never wrapped around the topology, so that it doesn't jump
+	starPos := shipPos.Sub(w.ShipWrap)
+
+	// Draw the starfield background with a rectangle shader
+	screen.DrawRectShader(g.screenWidth, g.screenHeight, starfield, &ebiten.DrawRectShaderOptions{
+		Uniforms: map[string]any{
+			"C":             w.C,                   // Pass in the speed of light
+			"RedshiftRed":   redshiftData[0],       // Pass in redshift color data for the red channel
+			"RedshiftGreen": redshiftData[1],       // Pass in redshift color data for the green channel
+			"RedshiftBlue":  redshiftData[2],       // Pass in redshift color data for the blue channel
+			"Position":      starPos.ToUniform(),   // Pass in the position of the ship
+			"Velocity":      w.Frame().ToUniform(), // Pass in the velocity of the frame the world is drawn in
+			"Seed":          g.shaderSeed,          // Pass in the seed
+			"Size":          screenSize(screen),    // Pass in the size of the screen
+		},
+	})
+
+	// Draw everything from the point of view of the ship
+	view := g.shipView()
+
+	// Initialise the color scale as nil
+	var colorScale *ebiten.ColorScale
+
+	// If the ship is invincible adjust the color scale to show this effect
+	if w.Invincibility {
+		colorScale = &ebiten.ColorScale{}
+		colorScale.ScaleWithColor(colorMix(colorDamage, color.White, math.Abs(math.Sin(w.Since(w.InvincibilityStart)*4*math.Pi))))
+	}
+
+	// Draw the ship, if thrusting, use alt texture
+	if w.Thrusting && !w.Ended {
+		drawParticle(screen, shipThrust, w.Ship, 64, view, colorScale)
+	} else if !w.Ended {
+		drawParticle(screen, ship, w.Ship, 64, view, colorScale)
+	}
+
+	// Draw the asteroids
+	drawPool(screen, w.Asteroids, g.asteroidSheet, view)
+
+	// Draw the bullets
+	drawPool(screen, w.Bullets, g.bulletSheet, view)
+
+	// Draw the explosion particles
+	drawPool(screen, w.Explosion, g.explosionSheet, view)
+
+	// Draw the clocks of the asteroids as they are seen from the ship
+	if g.clocks {
+		drawClocks(screen, w.Asteroids, g.asteroidSheet, view)
+	}
+
+	// Draw arrows to the closest bigAsteroid
+	if !w.Ended && !w.Sandbox {
+		closestPos := w.Asteroids.Closest(w.Ship.Pos)
+		drawArrow(screen, shipPos, closestPos)
+	}
+
+	// Draw the health of the ship, score and the speed of light, the sandbox has no health, ammo or score
+	if !w.Sandbox {
+		text.Draw(screen, fmt.Sprintf("♥ %d", w.Health), guiFont, 10, 24, colorHealth)
+		text.Draw(screen, fmt.Sprintf("! %d", w.Ammo), guiFont, 10, 48, colorDefault)
+		text.Draw(screen, fmt.Sprintf("SCORE: %04d", w.Score), guiFont, g.screenWidth-190, 24, colorDefault)
+	}
+
+	if w.Waves != nil {
+		text.Draw(screen, fmt.Sprintf("WAVE %d", w.Waves.Number), guiFont, 10, 72, colorDefault)
+	}
+
+	text.Draw(screen, fmt.Sprintf("v = %fc\nc = %sm/s", w.Ship.Vel.Mag()/w.C, scientificNotation(w.C)), guiFont, 10, g.screenHeight-28, colorDefault)
+
+	// Draw the time on the ship's clock next to the time in the rest frame of the asteroid field
+	text.Draw(screen, fmt.Sprintf("τ  = %.2fs\nt  = %.2fs\nΔt = %.2fs", w.Ship.Clock, w.Clock, w.Clock-w.Ship.Clock), guiFont, g.screenWidth-190, 60, colorClock)
+
+	if g.retarded {
+		text.Draw(screen, "RETARDED VIEW", guiFont, g.screenWidth-220, g.screenHeight-28, colorTitle)
+	} else if g.simultaneous {
+		text.Draw(screen, "SIMULTANEOUS VIEW", guiFont, g.screenWidth-284, g.screenHeight-28, colorTitle)
+	}
+
+	// Announce the end of each wave and the start of the next
+	if w.Waves != nil && !w.Ended {
+		if cleared, remaining := w.Waves.Cleared(w); cleared {
+			drawCentered(screen, fmt.Sprintf("WAVE %d CLEARED", w.Waves.Number), 100, colorSuccess)
+			drawCentered(screen, fmt.Sprintf("WAVE %d IN %d", w.Waves.Number+1, int(math.Ceil(remaining))), 124, colorDefault)
+		} else if w.Waves.Since(w) < waveTitleTime {
+			drawCentered(screen, fmt.Sprintf("WAVE %d", w.Waves.Number), 100, colorTitle)
+		}
+	}
+
+	// Introduce the scenario for the first few seconds
+	if w.Scenario != nil && w.Time() < scenarioTitleTime {
+		drawCentered(screen, w.Scenario.Name, 100, colorTitle)
+		drawCentered(screen, w.Scenario.Description, 124, colorDefault)
+	}
+
+	if g.diagram {
+		g.drawDiagram(screen)
+	}
+}
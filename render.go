@@ -0,0 +1,311 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/charmbracelet/log"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/lucasb-eyer/go-colorful"
+	"image/color"
+	"image/png"
+	"rp2/sim"
+)
+
+// SpriteSheet stores the sprites used to draw the particles of a pool
+type SpriteSheet struct {
+	Sprites []*ebiten.Image // Sprites indexed by the sprite index of each particle
+	Scale   float64         // Scale for particles
+}
+
+// NewSpriteSheet returns a new sprite sheet with the given draw scale and sprites.
+func NewSpriteSheet(scale float64, sprites ...*ebiten.Image) *SpriteSheet {
+	log.Debug("creating sprite sheet", "n", len(sprites), "scale", scale)
+	return &SpriteSheet{
+		Sprites: sprites,
+		Scale:   scale,
+	}
+}
+
+// View describes the observer that particles are drawn for
+type View struct {
+	Pos   sim.Vector // The position of the observer
+	Frame sim.Vector // The velocity of the observer
+	C     float64    // The speed of light
+	Time  float64    // The simulation time at which the observer is looking
+	Alpha float64    // How far between the previous and current tick particles are drawn, from 0 to 1
+
+	// Retarded draws particles where they were when the light reaching the observer left them, rather than where they
+	// are now. Only pools that track history can be drawn this way.
+	Retarded bool
+
+	// Simultaneous draws particles in the state they are in at the time the observer considers simultaneous with its
+	// own, rather than at the same time in the rest frame, so particles ahead of the observer are drawn later than
+	// those behind it. Retarded takes priority, as what the observer sees does not depend on its frame.
+	Simultaneous bool
+
+	Doppler    bool // Doppler tints particles by their doppler shift as seen by the observer
+	Aberration bool // Aberration draws particles in the direction the observer sees their light arriving from
+
+	// Topology is the shape of space, particles are drawn at their copy nearest the observer along with the copies
+	// across the seams that are on the screen. Space is an infinite plane if it is nil.
+	Topology sim.Topology
+}
+
+// offset returns the displacement from the observer to the nearest copy of a particle at pos
+func (v View) offset(pos sim.Vector) sim.Vector {
+	if v.Topology == nil {
+		return pos.Sub(v.Pos)
+	}
+
+	return v.Topology.Offset(v.Pos, pos)
+}
+
+// ghosts returns how far a particle at offset from the observer has to be moved to reach each of its other copies
+// within reach of the observer
+func (v View) ghosts(offset sim.Vector, reach float64) []sim.Vector {
+	if v.Topology == nil {
+		return nil
+	}
+
+	return v.Topology.Ghosts(offset, reach)
+}
+
+// aberrate returns the direction the observer sees light arriving from, for light that would arrive from direction n
+// if the observer were at rest
+func (v View) aberrate(n sim.Vector) sim.Vector {
+	// Boost the velocity of the light into the observer's frame, it travels from the source towards the observer
+	return sim.NewBoost(v.Frame, v.C).Velocity(n.Scl(-v.C)).Unit().Neg()
+}
+
+// dopplerTint returns the color that tints a particle by its doppler factor
+func dopplerTint(factor float64) (r, g, b float32) {
+	i := spectrumIndex(factor)
+	return float32(redshiftData[0][i]), float32(redshiftData[1][i]), float32(redshiftData[2][i])
+}
+
+// drawParticle draws the particle on the screen, along with its copies across the seams of the topology that are on
+// the screen
+func drawParticle(screen, sprite *ebiten.Image, p *sim.Particle, scale float64, view View, colorScale *ebiten.ColorScale) {
+	// Get the sprite dimensions
+	spriteDims := sim.Vector{
+		X: float64(sprite.Bounds().Dx()),
+		Y: float64(sprite.Bounds().Dy()),
+	}
+
+	// Get the screen dimensions
+	screenDims := sim.Vector{
+		X: float64(screen.Bounds().Dx()),
+		Y: float64(screen.Bounds().Dy()),
+	}
+
+	// Interpolate the particle between the previous and current tick
+	pos, angPos := p.Interpolate(view.Alpha)
+
+	// Get the boost into the frame of the particle
+	boost := sim.NewBoost(p.FrameVelocity(view.Frame, view.C), view.C)
+	axis := boost.V.Angle()
+
+	// Each copy is length contracted along the displacement from the observer to that copy, so copies across a seam
+	// are drawn where they would be if space carried on past it. Copies are only drawn if they can reach the screen.
+	nearest := view.offset(pos)
+	reach := screenDims.Mag()/(2*scale) + p.Radius
+
+	for _, shift := range append([]sim.Vector{{}}, view.ghosts(nearest, reach)...) {
+		offset, lineOfSight := seenFrom(p, view, nearest.Add(shift))
+
+		// Define the drawImageOptions
+		ops := new(ebiten.DrawImageOptions)
+
+		// First center the sprite
+		ops.GeoM.Translate(-spriteDims.X/2, -spriteDims.Y/2)
+
+		// Then rotate the sprite according to the angle of the particle and the angle of the reference frame
+		ops.GeoM.Rotate(angPos - axis)
+
+		// Then scale the particle, according the particle size and the length contraction acting on the particle
+		ops.GeoM.Scale((scale/spriteDims.X)*(p.Radius/boost.Gamma), (scale/spriteDims.Y)*p.Radius)
+
+		// Rotate back to the correct angle for the particle to be displayed at
+		ops.GeoM.Rotate(axis)
+
+		// Apply the color scale if necessary
+		if colorScale != nil {
+			ops.ColorScale = *colorScale
+		}
+
+		// Tint the particle by its doppler shift
+		if view.Doppler {
+			r, g, b := dopplerTint(sim.DopplerFactor(boost.V, lineOfSight, view.C))
+			ops.ColorScale.Scale(r, g, b, 1)
+		}
+
+		// Rotate back to the correct angle
+		ops.GeoM.Translate(scale*offset.X+screenDims.X/2, scale*offset.Y+screenDims.Y/2) // Translate to the correct position
+
+		// Draw the sprite to the screen
+		screen.DrawImage(sprite, ops)
+	}
+}
+
+// seenAt returns the offset from the observer that the nearest copy of the particle is drawn at, length contracted
+// along its velocity relative to the observer, and the direction it is seen in
+func seenAt(p *sim.Particle, view View) (offset, lineOfSight sim.Vector) {
+	// Interpolate the particle between the previous and current tick
+	pos, _ := p.Interpolate(view.Alpha)
+
+	return seenFrom(p, view, view.offset(pos))
+}
+
+// seenFrom returns the offset from the observer that a copy of the particle displaced by d from the observer is drawn
+// at, length contracted along its velocity relative to the observer, and the direction it is seen in
+func seenFrom(p *sim.Particle, view View, d sim.Vector) (offset, lineOfSight sim.Vector) {
+	// Length contract the offset from the observer
+	offset = sim.NewBoost(p.FrameVelocity(view.Frame, view.C), view.C).Contract(d)
+
+	// Get the direction the particle is seen in
+	if offset.SqrMag() > 0 {
+		lineOfSight = offset.Unit()
+
+		if view.Aberration {
+			lineOfSight = view.aberrate(lineOfSight)
+			offset = lineOfSight.Scl(offset.Mag())
+		}
+	}
+
+	return offset, lineOfSight
+}
+
+// drawClock draws the time on the clock of the particle above where the particle is drawn
+func drawClock(screen *ebiten.Image, p *sim.Particle, scale float64, view View) {
+	offset, _ := seenAt(p, view)
+
+	str := fmt.Sprintf("%.1f", p.Clock)
+	bounds := text.BoundString(guiFont, str)
+
+	// Centre the text horizontally on the particle and just above its top edge
+	x := scale*offset.X + float64(screen.Bounds().Dx())/2 - float64(bounds.Dx())/2
+	y := scale*(offset.Y-p.Radius/2) + float64(screen.Bounds().Dy())/2
+
+	text.Draw(screen, str, guiFont, int(x), int(y), colorClock)
+}
+
+// seen returns the particle at index i in the state the observer sees it in
+func seen(p *sim.Pool, i int, view View) *sim.Particle {
+	particle, h := p.Particle(i), p.History(i)
+
+	if h == nil {
+		return particle
+	}
+
+	// The history of the particle carries on across the seams of the topology, so it is looked up from the copy of
+	// the observer nearest to the particle
+	obs := particle.Pos.Sub(view.offset(particle.Pos))
+
+	switch {
+	case view.Retarded:
+		// The observer sees the particle where it was when its light left if the light travel time is taken into account
+		s, _ := h.Retarded(obs, view.Time, view.C)
+		particle = s.Apply(particle)
+	case view.Simultaneous:
+		// The state of the particle at the time that is now in the frame of the observer
+		s, _ := h.Simultaneous(obs, view.Frame, view.Time, view.C)
+		particle = s.Apply(particle)
+	}
+
+	return particle
+}
+
+// drawPool draws all particles in the pool to the screen using the given sprite sheet.
+func drawPool(screen *ebiten.Image, p *sim.Pool, sheet *SpriteSheet, view View) {
+	if sheet == nil {
+		return
+	}
+
+	indices, _ := p.ActiveIndices()
+
+	for _, i := range indices {
+		var colorScale *ebiten.ColorScale
+
+		if opacity := p.Opacity(i); opacity < 1 {
+			colorScale = new(ebiten.ColorScale)
+			colorScale.ScaleAlpha(float32(opacity))
+		}
+
+		drawParticle(screen, sheet.Sprites[p.Sprite(i)], seen(p, i, view), sheet.Scale, view, colorScale)
+	}
+}
+
+// drawClocks draws the clocks of all particles in the pool as the observer sees them, at the scale of the sprite sheet
+func drawClocks(screen *ebiten.Image, p *sim.Pool, sheet *SpriteSheet, view View) {
+	if sheet == nil {
+		return
+	}
+
+	indices, _ := p.ActiveIndices()
+
+	for _, i := range indices {
+		drawClock(screen, seen(p, i, view), sheet.Scale, view)
+	}
+}
+
+// drawArrow is used to draw an arrow on the screen from the ship to a target
+func drawArrow(screen *ebiten.Image, ship sim.Vector, target sim.Vector) {
+	// Get the sprite dimensions
+	spriteDims := sim.Vector{
+		X: float64(arrow.Bounds().Dx()),
+		Y: float64(arrow.Bounds().Dy()),
+	}
+
+	// Get the screen dimensions
+	screenDims := sim.Vector{
+		X: float64(screen.Bounds().Dx()),
+		Y: float64(screen.Bounds().Dy()),
+	}
+
+	// Get the angle between the ship and the target
+	angle := ship.AngleBetween(target)
+
+	// Rotate the arrow by the angle
+	ops := new(ebiten.DrawImageOptions)
+	ops.GeoM.Translate(-spriteDims.X/2, -spriteDims.Y/2)
+	ops.GeoM.Rotate(-angle)
+	ops.GeoM.Translate(screenDims.X/2, screenDims.Y/2)
+
+	// Give the arrow 50% opacity
+	ops.ColorScale.ScaleAlpha(0.5)
+
+	// Draw the arrow to the screen
+	screen.DrawImage(arrow, ops)
+}
+
+// screenSize returns the size of the screen as a shader uniform
+func screenSize(screen *ebiten.Image) [2]float64 {
+	return sim.Vector{X: float64(screen.Bounds().Dx()), Y: float64(screen.Bounds().Dy())}.ToUniform()
+}
+
+// drawCentered draws a line of text horizontally centred on the screen at the given height
+func drawCentered(screen *ebiten.Image, str string, y int, clr color.Color) {
+	x := (screen.Bounds().Dx() - text.BoundString(guiFont, str).Dx()) / 2
+	text.Draw(screen, str, guiFont, x, y, clr)
+}
+
+// getRedshift is used to convert the redshift data into a valid shader uniform
+func getRedshift() [3][100]float64 {
+	img, err := png.Decode(bytes.NewReader(spectraData))
+	if err != nil {
+		log.Fatal("failed to decode spectra data", "error", err)
+	}
+
+	out := [3][100]float64{}
+
+	for i := 0; i < 100; i++ {
+		col, _ := colorful.MakeColor(img.At(i, 0))
+
+		out[0][i] = col.R
+		out[1][i] = col.G
+		out[2][i] = col.B
+	}
+
+	return out
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"fmt"
+	"github.com/charmbracelet/log"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"math"
+	"rp2/sim"
+)
+
+// Defining the constants of the sandbox controls.
+const (
+	// How fast holding a key changes a setting, by a factor of 10 every second
+	sandboxRate = math.Ln10
+
+	// How many pixels the mouse is dragged to give a particle a speed of c
+	sandboxAimLength = 200
+
+	// The fastest a particle can be given, as a fraction of c
+	sandboxMaxBeta = 0.99
+)
+
+// sandboxSetting is a physical setting of the sandbox that is changed while a key is held
+type sandboxSetting struct {
+	label    string                        // The keys and name of the setting shown on the screen
+	down, up ebiten.Key                    // The keys that decrease and increase the setting
+	min, max float64                       // The range of the setting
+	off      bool                          // Whether decreasing the setting below its minimum turns it off
+	get      func(g *Game) float64         // Returns the current value of the setting
+	set      func(g *Game, v float64)      // Sets the value of the setting
+	format   func(v float64) string        // Formats the value of the setting
+	reset    func(g *Game, cfg sim.Config) // Resets the setting to its value in the config
+}
+
+// sandboxSettings are the settings that can be changed in the sandbox
+var sandboxSettings = []sandboxSetting{
+	{
+		label: "1/2 c",
+		down:  ebiten.Key1, up: ebiten.Key2,
+		min: 1, max: 299792458,
+		get:    func(g *Game) float64 { return g.world.C },
+		set:    func(g *Game, v float64) { g.world.SetC(v) },
+		format: func(v float64) string { return scientificNotation(v) + "m/s" },
+		reset:  func(g *Game, cfg sim.Config) { g.world.SetC(cfg.C) },
+	},
+	{
+		label: "3/4 drag",
+		down:  ebiten.Key3, up: ebiten.Key4,
+		min: 0.001, max: 10, off: true,
+		get:    func(g *Game) float64 { return g.world.Config.Drag },
+		set:    func(g *Game, v float64) { g.world.Config.Drag = v },
+		format: func(v float64) string { return fmt.Sprintf("%.3f", v) },
+		reset:  func(g *Game, cfg sim.Config) { g.world.Config.Drag = cfg.Drag },
+	},
+	{
+		label: "5/6 thrust",
+		down:  ebiten.Key5, up: ebiten.Key6,
+		min: 0.1, max: 1000, off: true,
+		get:    func(g *Game) float64 { return g.world.Config.Thrust },
+		set:    func(g *Game, v float64) { g.world.Config.Thrust = v },
+		format: func(v float64) string { return fmt.Sprintf("%.1fN", v) },
+		reset:  func(g *Game, cfg sim.Config) { g.world.Config.Thrust = cfg.Thrust },
+	},
+	{
+		label: "7/8 time",
+		down:  ebiten.Key7, up: ebiten.Key8,
+		min: 1.0 / 16, max: 4,
+		get:    func(g *Game) float64 { return g.timeScale },
+		set:    func(g *Game, v float64) { g.timeScale = v },
+		format: func(v float64) string { return fmt.Sprintf("%.2fx", v) },
+		reset:  func(g *Game, _ sim.Config) { g.timeScale = 1 },
+	},
+}
+
+// update changes the setting while one of its keys is held
+func (s sandboxSetting) update(g *Game) {
+	dir := 0.0
+	if ebiten.IsKeyPressed(s.down) {
+		dir--
+	}
+
+	if ebiten.IsKeyPressed(s.up) {
+		dir++
+	}
+
+	if dir == 0 {
+		return
+	}
+
+	v := s.get(g)
+
+	switch {
+	case v == 0 && dir > 0:
+		// Turn the setting back on from its minimum
+		v = s.min
+	case v*math.Exp(dir*sandboxRate*g.delta) < s.min && s.off:
+		v = 0
+	default:
+		v = math.Max(s.min, math.Min(s.max, v*math.Exp(dir*sandboxRate*g.delta)))
+	}
+
+	s.set(g, v)
+}
+
+// sandboxScene is a laboratory for experimenting with the physics, without health, ammo or score. The physical
+// settings are changed live with the keyboard, and asteroids are spawned, moved and given velocities with the mouse.
+type sandboxScene struct {
+	paused bool // Whether time is frozen, particles can still be moved and given velocities
+	fire   bool // Whether the player has fired since the previous step, as there may be no step in an update
+
+	held  *sim.Particle // The asteroid being moved with the left mouse button
+	aimed *sim.Particle // The asteroid or ship being given a velocity with the right mouse button
+}
+
+// Enter does nothing, the sandbox is started by Game.startSandbox
+func (s *sandboxScene) Enter(*Game) {}
+
+// Exit does nothing
+func (s *sandboxScene) Exit(*Game) {}
+
+// Update changes the settings, handles the mouse and steps the world unless it is paused
+func (s *sandboxScene) Update(g *Game) error {
+	if g.controls.JustPressed(ActionPause) {
+		log.Debug("leaving sandbox")
+		g.Switch(&mainMenuScene{})
+		return nil
+	}
+
+	g.viewUpdate()
+
+	for _, setting := range sandboxSettings {
+		setting.update(g)
+	}
+
+	// Reset the settings to the config
+	if inpututil.IsKeyJustPressed(sandboxResetKey) {
+		log.Debug("resetting sandbox settings")
+		for _, setting := range sandboxSettings {
+			setting.reset(g, g.cfg)
+		}
+	}
+
+	// Freeze and unfreeze time
+	if inpututil.IsKeyJustPressed(sandboxPauseKey) {
+		s.paused = !s.paused
+		log.Debug("toggled sandbox pause", "paused", s.paused)
+	}
+
+	// Remove every asteroid
+	if inpututil.IsKeyJustPressed(sandboxClearKey) {
+		log.Debug("clearing sandbox")
+		g.world.Asteroids.Reset()
+		s.held, s.aimed = nil, nil
+	}
+
+	s.mouseUpdate(g)
+
+	if s.paused {
+		return nil
+	}
+
+	controls := g.controls.Input()
+	s.fire = s.fire || controls.Fire
+
+	g.fixedUpdate(func() {
+		in := controls
+		in.Fire = s.fire
+		in.Escape = false
+
+		g.world.Step(in)
+		s.fire = false
+
+		// Keep the held particle under the mouse
+		if s.held != nil {
+			s.hold(g)
+		}
+	})
+
+	return nil
+}
+
+// mouseUpdate spawns and moves asteroids with the left mouse button and aims them or the ship with the right mouse
+// button. The ship can't be moved, as the view is centred on it.
+func (s *sandboxScene) mouseUpdate(g *Game) {
+	view := g.shipView()
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		s.held = g.particleAt(mouseScreen(), view)
+
+		// Clicking on empty space spawns an asteroid at rest
+		if s.held == nil {
+			s.held = g.world.SpawnAsteroid(g.screenToWorld(mouseScreen(), view), sim.Vector{})
+		}
+	}
+
+	if s.held != nil {
+		s.hold(g)
+	}
+
+	if inpututil.IsMouseButtonJustReleased(ebiten.MouseButtonLeft) {
+		s.held = nil
+	}
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight) {
+		s.aimed = g.particleAt(mouseScreen(), view)
+
+		// The ship is always drawn in the centre of the screen
+		if s.aimed == nil && g.worldScreen(sim.Vector{}).Dist(mouseScreen()) < g.asteroidSheet.Scale*g.world.Ship.Radius/2 {
+			s.aimed = g.world.Ship
+		}
+	}
+
+	if inpututil.IsMouseButtonJustReleased(ebiten.MouseButtonRight) && s.aimed != nil {
+		vel := s.aim(g)
+		log.Debug("setting particle velocity", "vel", vel.String())
+		s.aimed.SetFourMomentum(sim.NewFourMomentum(s.aimed.Mass, vel, g.world.C), g.world.C)
+		s.aimed = nil
+	}
+}
+
+// hold moves the held particle to the mouse
+func (s *sandboxScene) hold(g *Game) {
+	s.held.Pos = g.screenToWorld(mouseScreen(), g.shipView())
+	s.held.SavePrevious()
+}
+
+// aim returns the velocity that the aimed particle is given, from the particle to the mouse
+func (s *sandboxScene) aim(g *Game) sim.Vector {
+	offset, _ := seenAt(s.aimed, g.shipView())
+	drag := mouseScreen().Sub(g.worldScreen(offset))
+
+	beta := math.Min(drag.Mag()/sandboxAimLength, sandboxMaxBeta)
+	return drag.SetMag(beta * g.world.C)
+}
+
+// Draw draws the world, the velocity being aimed and the settings
+func (s *sandboxScene) Draw(g *Game, screen *ebiten.Image) {
+	g.worldDraw(screen)
+
+	// Draw the velocity being aimed from the particle to the mouse
+	if s.aimed != nil {
+		offset, _ := seenAt(s.aimed, g.shipView())
+		from, to := g.worldScreen(offset), mouseScreen()
+		vector.StrokeLine(screen, float32(from.X), float32(from.Y), float32(to.X), float32(to.Y), 2, colorTitle, true)
+		text.Draw(screen, fmt.Sprintf("%.2fc", s.aim(g).Mag()/g.world.C), guiFont, int(to.X)+12, int(to.Y), colorTitle)
+	}
+
+	for i, setting := range sandboxSettings {
+		text.Draw(screen, fmt.Sprintf("%-11s %s", setting.label, setting.format(setting.get(g))), guiFont, 10, 24+i*24, colorDefault)
+	}
+
+	text.Draw(screen, "0 reset  P pause  BKSP clear", guiFont, 10, 24+len(sandboxSettings)*24, colorFaint)
+	text.Draw(screen, "LMB spawn/move  RMB aim", guiFont, 10, 48+len(sandboxSettings)*24, colorFaint)
+
+	if s.paused {
+		drawCentered(screen, "PAUSED", g.screenHeight/2-80, colorTitle)
+	}
+}
+
+// mouseScreen returns the position of the mouse on the screen
+func mouseScreen() sim.Vector {
+	x, y := ebiten.CursorPosition()
+	return sim.Vector{X: float64(x), Y: float64(y)}
+}
+
+// worldScreen returns the position on the screen of an offset from the observer in world units
+func (g *Game) worldScreen(offset sim.Vector) sim.Vector {
+	return offset.Scl(g.asteroidSheet.Scale).Add(sim.Vector{X: float64(g.screenWidth) / 2, Y: float64(g.screenHeight) / 2})
+}
+
+// screenToWorld returns the position in the world under a point on the screen, ignoring length contraction
+func (g *Game) screenToWorld(pos sim.Vector, view View) sim.Vector {
+	return view.Pos.Add(pos.Sub(sim.Vector{X: float64(g.screenWidth) / 2, Y: float64(g.screenHeight) / 2}).Scl(1 / g.asteroidSheet.Scale))
+}
+
+// particleAt returns the asteroid drawn under a point on the screen, or nil if there is none
+func (g *Game) particleAt(pos sim.Vector, view View) *sim.Particle {
+	w := g.world
+
+	var out *sim.Particle
+	best := math.MaxFloat64
+
+	// check picks the particle if it is drawn under the point and closer than the best so far
+	check := func(p, drawn *sim.Particle) {
+		offset, _ := seenAt(drawn, view)
+		d := g.worldScreen(offset).Dist(pos)
+
+		// Sprites are drawn with a width of the scale times the radius
+		if d < g.asteroidSheet.Scale*drawn.Radius/2 && d < best {
+			out, best = p, d
+		}
+	}
+
+	indices, particles := w.Asteroids.ActiveIndices()
+
+	for k, i := range indices {
+		check(particles[k], seen(w.Asteroids, i, view))
+	}
+
+	return out
+}
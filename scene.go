@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"github.com/charmbracelet/log"
+	"github.com/hajimehoshi/ebiten/v2"
+	"time"
+)
+
+// Scene is a screen of the game, such as a menu or a run being played. Scenes are kept on a stack by the game, only
+// the scene on top is updated, while every scene on the stack is drawn from the bottom up so that scenes can be
+// overlaid on each other.
+type Scene interface {
+	// Enter is called when the scene is added to the stack
+	Enter(g *Game)
+
+	// Exit is called when the scene is removed from the stack
+	Exit(g *Game)
+
+	// Update is called every physics update while the scene is on top of the stack
+	Update(g *Game) error
+
+	// Draw is called every frame while the scene is on the stack
+	Draw(g *Game, screen *ebiten.Image)
+}
+
+// Push adds a scene on top of the stack
+func (g *Game) Push(s Scene) {
+	log.Debug("pushing scene", "scene", fmt.Sprintf("%T", s))
+	g.scenes = append(g.scenes, s)
+	s.Enter(g)
+}
+
+// Pop removes the scene on top of the stack
+func (g *Game) Pop() {
+	if len(g.scenes) == 0 {
+		return
+	}
+
+	s := g.scenes[len(g.scenes)-1]
+	log.Debug("popping scene", "scene", fmt.Sprintf("%T", s))
+
+	g.scenes = g.scenes[:len(g.scenes)-1]
+	s.Exit(g)
+}
+
+// Switch replaces every scene on the stack with the given scene
+func (g *Game) Switch(s Scene) {
+	for len(g.scenes) > 0 {
+		g.Pop()
+	}
+
+	g.Push(s)
+}
+
+// Scene returns the scene on top of the stack, or nil if the stack is empty
+func (g *Game) Scene() Scene {
+	if len(g.scenes) == 0 {
+		return nil
+	}
+
+	return g.scenes[len(g.scenes)-1]
+}
+
+// screenTimer is embedded in scenes to keep track of how long they have been shown for
+type screenTimer struct {
+	start time.Time // The time at which the scene was entered
+}
+
+// Enter starts the timer
+func (t *screenTimer) Enter(*Game) {
+	t.start = time.Now()
+}
+
+// Exit does nothing
+func (t *screenTimer) Exit(*Game) {}
+
+// elapsed returns how long the scene has been shown for in seconds
+func (t *screenTimer) elapsed() float64 {
+	return time.Since(t.start).Seconds()
+}
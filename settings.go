@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"github.com/charmbracelet/log"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"image/color"
+	"slices"
+)
+
+// actionLabels are the names of the actions shown on the controls screen
+var actionLabels = [actionCount]string{
+	ActionThrust:      "Thrust",
+	ActionRotateLeft:  "Rotate left",
+	ActionRotateRight: "Rotate right",
+	ActionFire:        "Fire",
+	ActionPause:       "Pause",
+}
+
+// controlsScene lets the player rebind the keys and gamepad buttons of each action
+type controlsScene struct {
+	screenTimer
+
+	selected Action     // The selected action
+	waiting  bool       // Whether the scene is waiting for a key or button to bind to the selected action
+	reserved ebiten.Key // The last reserved key pressed while waiting, shown until another key or button is pressed
+	rejected bool       // Whether a reserved key was pressed while waiting
+}
+
+// Update moves the selection, and rebinds the selected action to the next key or button pressed
+func (s *controlsScene) Update(g *Game) error {
+	g.bgScroll -= g.delta * 10
+
+	if s.waiting {
+		s.rebind(g)
+		return nil
+	}
+
+	if menuUp() {
+		s.selected = (s.selected + actionCount - 1) % actionCount
+	}
+
+	if menuDown() {
+		s.selected = (s.selected + 1) % actionCount
+	}
+
+	if menuConfirm() {
+		log.Debug("waiting for binding", "action", s.selected)
+		s.waiting = true
+	}
+
+	if anyJustPressed([]ebiten.Key{ebiten.KeyBackspace, ebiten.KeyDelete}, []ebiten.StandardGamepadButton{ebiten.StandardGamepadButtonRightLeft}) {
+		log.Debug("resetting binding", "action", s.selected)
+		g.controls.Reset(s.selected)
+	}
+
+	if menuBack() {
+		if err := g.controls.Save(); err != nil {
+			log.Error("failed to save controls", "error", err)
+		}
+
+		log.Debug("moving to main menu")
+		g.Switch(&mainMenuScene{})
+	}
+
+	return nil
+}
+
+// rebind binds the selected action to the first key or gamepad button pressed, escape cancels
+func (s *controlsScene) rebind(g *Game) {
+	for _, k := range inpututil.AppendJustPressedKeys(nil) {
+		if k == ebiten.KeyEscape {
+			log.Debug("cancelled binding")
+			s.waiting, s.rejected = false, false
+			return
+		}
+
+		// The view and sandbox keys are fixed, so keep waiting for another key
+		if reservedKey(k) {
+			log.Debug("rejected reserved key", "action", s.selected, "key", k)
+			s.reserved, s.rejected = k, true
+			return
+		}
+
+		s.waiting, s.rejected = false, false
+
+		// A key can only be bound to one action at a time
+		for _, b := range g.controls.Bindings {
+			b.Keys = slices.DeleteFunc(b.Keys, func(other ebiten.Key) bool { return other == k })
+		}
+
+		log.Debug("bound key", "action", s.selected, "key", k)
+		g.controls.Bindings[s.selected] = &Binding{
+			Keys:    []ebiten.Key{k},
+			Buttons: g.controls.Bindings[s.selected].Buttons,
+		}
+
+		return
+	}
+
+	for _, id := range gamepads() {
+		for _, button := range inpututil.AppendJustPressedStandardGamepadButtons(id, nil) {
+			s.waiting, s.rejected = false, false
+
+			// A button can only be bound to one action at a time
+			for _, b := range g.controls.Bindings {
+				b.Buttons = slices.DeleteFunc(b.Buttons, func(other GamepadButton) bool { return other == GamepadButton(button) })
+			}
+
+			log.Debug("bound gamepad button", "action", s.selected, "button", GamepadButton(button))
+			g.controls.Bindings[s.selected] = &Binding{
+				Keys:    g.controls.Bindings[s.selected].Keys,
+				Buttons: []GamepadButton{GamepadButton(button)},
+			}
+
+			return
+		}
+	}
+}
+
+// Draw draws the bindings of every action
+func (s *controlsScene) Draw(g *Game, screen *ebiten.Image) {
+	g.menuBackgroundDraw(screen)
+
+	drawCentered(screen, "CONTROLS", 24, colorTitle)
+
+	for a := Action(0); a < actionCount; a++ {
+		clr := color.Color(colorDefault)
+		if a == s.selected {
+			clr = colorSuccess
+		}
+
+		binding := g.controls.Bindings[a].String()
+		if a == s.selected && s.waiting {
+			binding = "..."
+		}
+
+		text.Draw(screen, fmt.Sprintf("%-13s %s", actionLabels[a], binding), guiFont, 10, 100+int(a)*24, clr)
+	}
+
+	if s.waiting {
+		if s.rejected {
+			drawCentered(screen, fmt.Sprintf("%s is reserved for the views and sandbox", s.reserved), g.screenHeight-76, colorFailure)
+		}
+
+		drawCentered(screen, fmt.Sprintf("Press a key or button for %s", actionLabels[s.selected]), g.screenHeight-52, colorDefault)
+		drawCentered(screen, "Press escape to cancel", g.screenHeight-28, colorDefault)
+	} else {
+		drawCentered(screen, "Enter to rebind, backspace to reset", g.screenHeight-52, colorDefault)
+		drawCentered(screen, "Press escape to return", g.screenHeight-28, colorDefault)
+	}
+}
@@ -0,0 +1,257 @@
+package sim
+
+import (
+	"math"
+	"sort"
+)
+
+// BroadPhase finds the pairs of particles that could be colliding, which are then checked with CheckCollision
+type BroadPhase interface {
+	// Pairs returns the index pairs (i < j) of particles that could be colliding, sorted by i and then j
+	Pairs(particles []*Particle) [][2]int
+
+	// CrossPairs returns the index pairs of particles from a and b that could be colliding, sorted by a and then b
+	CrossPairs(a, b []*Particle) [][2]int
+}
+
+// reach returns the furthest distance from its center at which a particle can collide.
+// Length contraction in CheckCollision only ever shrinks the radius, so this is the scaled radius.
+func reach(p *Particle) float64 {
+	return p.ScaledRadius()
+}
+
+// maxReach returns the largest reach of the given particles
+func maxReach(particles ...[]*Particle) float64 {
+	out := 0.0
+
+	for _, ps := range particles {
+		for _, p := range ps {
+			out = math.Max(out, reach(p))
+		}
+	}
+
+	return out
+}
+
+// sortPairs sorts index pairs by the first and then the second index
+func sortPairs(pairs [][2]int) [][2]int {
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+
+		return pairs[i][1] < pairs[j][1]
+	})
+
+	return pairs
+}
+
+// BruteForce is a broad phase that returns every pair of particles
+type BruteForce struct{}
+
+// Pairs returns every pair of particles
+func (BruteForce) Pairs(particles []*Particle) [][2]int {
+	out := make([][2]int, 0, len(particles)*(len(particles)-1)/2)
+
+	for i := 0; i < len(particles)-1; i++ {
+		for j := i + 1; j < len(particles); j++ {
+			out = append(out, [2]int{i, j})
+		}
+	}
+
+	return out
+}
+
+// CrossPairs returns every pair of particles from a and b
+func (BruteForce) CrossPairs(a, b []*Particle) [][2]int {
+	out := make([][2]int, 0, len(a)*len(b))
+
+	for i := 0; i < len(a); i++ {
+		for j := 0; j < len(b); j++ {
+			out = append(out, [2]int{i, j})
+		}
+	}
+
+	return out
+}
+
+// SpatialHash is a broad phase that buckets particles into a uniform grid and only pairs particles in neighbouring cells
+type SpatialHash struct {
+	CellSize float64 // The size of the grid cells, if 0 it is sized from the largest ScaledRadius of the particles
+}
+
+// cell returns the grid cell containing the position
+func (s SpatialHash) cell(pos Vector, size float64) [2]int {
+	return [2]int{int(math.Floor(pos.X / size)), int(math.Floor(pos.Y / size))}
+}
+
+// cellSize returns the size of the grid cells to use for the given particles
+func (s SpatialHash) cellSize(particles ...[]*Particle) float64 {
+	// Cells must be at least as wide as the furthest two particles can be apart and still collide
+	size := 2 * maxReach(particles...)
+	if s.CellSize > size {
+		size = s.CellSize
+	}
+
+	return size
+}
+
+// build buckets the particles into grid cells
+func (s SpatialHash) build(particles []*Particle, size float64) map[[2]int][]int {
+	grid := make(map[[2]int][]int)
+
+	for i, p := range particles {
+		c := s.cell(p.Pos, size)
+		grid[c] = append(grid[c], i)
+	}
+
+	return grid
+}
+
+// neighbours calls f with the particle indices in the 3x3 block of cells around pos
+func (s SpatialHash) neighbours(grid map[[2]int][]int, pos Vector, size float64, f func(j int)) {
+	c := s.cell(pos, size)
+
+	for x := c[0] - 1; x <= c[0]+1; x++ {
+		for y := c[1] - 1; y <= c[1]+1; y++ {
+			for _, j := range grid[[2]int{x, y}] {
+				f(j)
+			}
+		}
+	}
+}
+
+// Pairs returns the pairs of particles in neighbouring cells
+func (s SpatialHash) Pairs(particles []*Particle) [][2]int {
+	out := make([][2]int, 0)
+
+	size := s.cellSize(particles)
+	if size == 0 {
+		return out
+	}
+
+	grid := s.build(particles, size)
+
+	for i, p := range particles {
+		s.neighbours(grid, p.Pos, size, func(j int) {
+			if j > i {
+				out = append(out, [2]int{i, j})
+			}
+		})
+	}
+
+	return sortPairs(out)
+}
+
+// CrossPairs returns the pairs of particles from a and b in neighbouring cells
+func (s SpatialHash) CrossPairs(a, b []*Particle) [][2]int {
+	out := make([][2]int, 0)
+
+	size := s.cellSize(a, b)
+	if size == 0 {
+		return out
+	}
+
+	grid := s.build(b, size)
+
+	for i, p := range a {
+		s.neighbours(grid, p.Pos, size, func(j int) {
+			out = append(out, [2]int{i, j})
+		})
+	}
+
+	return sortPairs(out)
+}
+
+// SweepAndPrune is a broad phase that sorts the particles along the x-axis and only pairs particles whose
+// bounding boxes overlap
+type SweepAndPrune struct{}
+
+// sapEntry is a particle's bounding box on the x-axis during a sweep
+type sapEntry struct {
+	min, max float64 // The extent of the bounding box on the x-axis
+	set      int     // Which set of particles the particle belongs to
+	i        int     // The index of the particle in its set
+}
+
+// sweep sorts the bounding boxes and calls f for every pair that overlaps on the x-axis
+func (SweepAndPrune) sweep(entries []sapEntry, f func(a, b sapEntry)) {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].min < entries[j].min
+	})
+
+	active := make([]sapEntry, 0)
+
+	for _, e := range entries {
+		// Remove boxes that end before this one starts
+		n := 0
+		for _, a := range active {
+			if a.max >= e.min {
+				active[n] = a
+				n++
+			}
+		}
+		active = active[:n]
+
+		for _, a := range active {
+			f(a, e)
+		}
+
+		active = append(active, e)
+	}
+}
+
+// entries returns the bounding boxes of the particles on the x-axis
+func (SweepAndPrune) entries(entries []sapEntry, particles []*Particle, set int) []sapEntry {
+	for i, p := range particles {
+		r := reach(p)
+		entries = append(entries, sapEntry{min: p.Pos.X - r, max: p.Pos.X + r, set: set, i: i})
+	}
+
+	return entries
+}
+
+// overlapY returns whether the bounding boxes of two particles overlap on the y-axis
+func overlapY(p, q *Particle) bool {
+	return math.Abs(p.Pos.Y-q.Pos.Y) <= reach(p)+reach(q)
+}
+
+// Pairs returns the pairs of particles whose bounding boxes overlap
+func (s SweepAndPrune) Pairs(particles []*Particle) [][2]int {
+	out := make([][2]int, 0)
+
+	s.sweep(s.entries(nil, particles, 0), func(a, b sapEntry) {
+		if !overlapY(particles[a.i], particles[b.i]) {
+			return
+		}
+
+		if a.i < b.i {
+			out = append(out, [2]int{a.i, b.i})
+		} else {
+			out = append(out, [2]int{b.i, a.i})
+		}
+	})
+
+	return sortPairs(out)
+}
+
+// CrossPairs returns the pairs of particles from a and b whose bounding boxes overlap
+func (s SweepAndPrune) CrossPairs(a, b []*Particle) [][2]int {
+	out := make([][2]int, 0)
+
+	s.sweep(s.entries(s.entries(nil, a, 0), b, 1), func(x, y sapEntry) {
+		if x.set == y.set {
+			return
+		}
+
+		if x.set == 1 {
+			x, y = y, x
+		}
+
+		if overlapY(a[x.i], b[y.i]) {
+			out = append(out, [2]int{x.i, y.i})
+		}
+	})
+
+	return sortPairs(out)
+}
@@ -0,0 +1,109 @@
+package sim
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// broadPhases are the broad phases under test
+var broadPhases = map[string]BroadPhase{
+	"BruteForce":    BruteForce{},
+	"SpatialHash":   SpatialHash{},
+	"SweepAndPrune": SweepAndPrune{},
+}
+
+// randomParticles returns n asteroids spread over a disc at the same density as the game
+func randomParticles(rng *rand.Rand, n int) []*Particle {
+	area := 20 * math.Sqrt(float64(n)/64)
+
+	return newAsteroidPool(rng, n, area).Active()
+}
+
+// narrowPhase returns the pairs from the broad phase that actually collide
+func narrowPhase(particles []*Particle, pairs [][2]int) [][2]int {
+	out := make([][2]int, 0)
+
+	for _, pair := range pairs {
+		if particles[pair[0]].CheckCollision(particles[pair[1]], Vector{}, 299792458.0, Unbounded{}) {
+			out = append(out, pair)
+		}
+	}
+
+	return out
+}
+
+func TestBroadPhasesMatchBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for trial := 0; trial < 20; trial++ {
+		particles := randomParticles(rng, 200)
+		others := randomParticles(rng, 50)
+
+		want := narrowPhase(particles, BruteForce{}.Pairs(particles))
+		wantCross := BruteForce{}.CrossPairs(particles, others)
+
+		for name, bp := range broadPhases {
+			if got := narrowPhase(particles, bp.Pairs(particles)); !reflect.DeepEqual(got, want) {
+				t.Fatalf("%s: got pairs %v, want %v", name, got, want)
+			}
+
+			// Check that every colliding cross pair is a candidate
+			candidates := make(map[[2]int]bool)
+			for _, pair := range bp.CrossPairs(particles, others) {
+				candidates[pair] = true
+			}
+
+			for _, pair := range wantCross {
+				if particles[pair[0]].CheckCollision(others[pair[1]], Vector{}, 299792458.0, Unbounded{}) && !candidates[pair] {
+					t.Fatalf("%s: missing cross pair %v", name, pair)
+				}
+			}
+		}
+	}
+}
+
+func TestWorldBroadPhase(t *testing.T) {
+	for name, want := range map[string]BroadPhase{"brute": BruteForce{}, "hash": SpatialHash{}, "sweep": SweepAndPrune{}} {
+		cfg := DefaultConfig()
+		cfg.BroadPhase = name
+
+		// Hits on asteroids are found by the broad phase of the bullets pool
+		w := NewWorld(1, cfg)
+		if w.Asteroids.broadPhase != want || w.Bullets.broadPhase != want {
+			t.Errorf("%s: asteroids use %T and bullets use %T", name, w.Asteroids.broadPhase, w.Bullets.broadPhase)
+		}
+	}
+}
+
+func BenchmarkSolveCollisions(b *testing.B) {
+	for _, n := range []int{1000, 10000} {
+		for _, name := range []string{"BruteForce", "SpatialHash", "SweepAndPrune"} {
+			b.Run(fmt.Sprintf("%s/%d", name, n), func(b *testing.B) {
+				particles := randomParticles(rand.New(rand.NewSource(1)), n)
+
+				b.ResetTimer()
+
+				for i := 0; i < b.N; i++ {
+					SolveCollisions(particles, Vector{}, 299792458.0, broadPhases[name], Elastic{}, Unbounded{})
+				}
+			})
+
+			// As many bullets as asteroids, found by the broad phase of the bullets pool
+			b.Run(fmt.Sprintf("PoolCollisions/%s/%d", name, n), func(b *testing.B) {
+				rng := rand.New(rand.NewSource(1))
+				area := 20 * math.Sqrt(float64(n)/64)
+				asteroids := newAsteroidPool(rng, n, area)
+				bullets := newAsteroidPool(rng, n, area).SetBroadPhase(broadPhases[name])
+
+				b.ResetTimer()
+
+				for i := 0; i < b.N; i++ {
+					bullets.PoolCollisions(asteroids, Vector{}, 299792458.0)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package sim
+
+import (
+	"math"
+)
+
+// CollisionModel decides how a pair of colliding particles respond to the collision
+type CollisionModel interface {
+	// Collide responds to a collision between p and q, where normal is the unit vector along the collision axis pointing
+	// from q to p. Returns true if q has been merged into p and should be removed.
+	Collide(p, q *Particle, normal Vector, c float64) bool
+}
+
+// Elastic is a collision model where particles bounce off each other without losing any kinetic energy
+type Elastic struct{}
+
+// Collide performs an elastic collision
+func (Elastic) Collide(p, q *Particle, normal Vector, c float64) bool {
+	collideElastic(p, q, normal, c)
+	return false
+}
+
+// Restitution is a collision model where particles bounce off each other, keeping a fraction of their speed along the
+// collision axis in the centre of momentum frame. The kinetic energy that is lost heats the particles, increasing their
+// rest mass so that the total four-momentum is conserved.
+type Restitution struct {
+	Coefficient float64 // The coefficient of restitution, 1 is elastic and 0 is perfectly inelastic
+}
+
+// Collide performs an inelastic collision
+func (r Restitution) Collide(p, q *Particle, normal Vector, c float64) bool {
+	collideInelastic(p, q, normal, c, r.Coefficient)
+	return false
+}
+
+// Merge is a perfectly inelastic collision model where colliding particles merge into a single particle. The rest mass
+// of the merged particle is the invariant mass of the pair, which is greater than the sum of their rest masses by the
+// kinetic energy lost in the collision divided by c².
+type Merge struct{}
+
+// Collide merges q into p
+func (Merge) Collide(p, q *Particle, normal Vector, c float64) bool {
+	pMomentum := p.FourMomentum(c)
+	qMomentum := q.FourMomentum(c)
+	momentum := pMomentum.Add(qMomentum)
+
+	// Place the merged particle at the centre of energy of the pair
+	p.Pos = p.Pos.Scl(pMomentum.T).Add(q.Pos.Scl(qMomentum.T)).Scl(1 / momentum.T)
+
+	// Combine the spins weighted by the moments of inertia
+	pInertia := p.Mass * p.Radius * p.Radius
+	qInertia := q.Mass * q.Radius * q.Radius
+	p.AngVel = (pInertia*p.AngVel + qInertia*q.AngVel) / (pInertia + qInertia)
+
+	// Keep the total area of the pair
+	p.Radius = math.Hypot(p.Radius, q.Radius)
+
+	p.Mass = momentum.Mass(c)
+	p.SetFourMomentum(momentum, c)
+
+	return true
+}
+
+// externalModel wraps a collision model so that collisions with particles from outside the pool are always elastic,
+// as they cannot be merged into the pool
+type externalModel struct {
+	CollisionModel
+	external []*Particle // The particles from outside the pool
+}
+
+// Collide performs an elastic collision if either particle is from outside the pool, otherwise it uses the wrapped model
+func (m externalModel) Collide(p, q *Particle, normal Vector, c float64) bool {
+	for _, e := range m.external {
+		if p == e || q == e {
+			return Elastic{}.Collide(p, q, normal, c)
+		}
+	}
+
+	return m.CollisionModel.Collide(p, q, normal, c)
+}
+
+// collideElastic performs a relativistic elastic collision between two particles, where normal is the unit vector
+// along the collision axis pointing from q to p.
+//
+// Both particles are boosted into the centre of momentum frame, where the components of their momenta along the
+// contact normal are reflected, and are then boosted back. Reflecting leaves the energy of each particle unchanged in
+// the centre of momentum frame, so the total four-momentum is conserved. Particles that are already separating along
+// the normal are left alone.
+func collideElastic(p, q *Particle, normal Vector, c float64) {
+	pMomentum := p.FourMomentum(c)
+	qMomentum := q.FourMomentum(c)
+
+	// Get the boost into the centre of momentum frame of the system
+	CoM := NewBoost(pMomentum.Add(qMomentum).Velocity(c), c)
+
+	// Get the momenta of the particles in the centre of momentum frame
+	pMomentum = CoM.Momentum(pMomentum)
+	qMomentum = CoM.Momentum(qMomentum)
+
+	// Get the contact normal in the centre of momentum frame
+	normal = comNormal(CoM, pMomentum, qMomentum, normal, c)
+
+	// Only collide particles that are moving towards each other
+	if pMomentum.Spatial().Sub(qMomentum.Spatial()).Dot(normal) >= 0 {
+		return
+	}
+
+	// Reflect the momenta along the normal and transform back to the original reference frame
+	p.SetFourMomentum(CoM.Inverse().Momentum(reflectNormal(pMomentum, normal)), c)
+	q.SetFourMomentum(CoM.Inverse().Momentum(reflectNormal(qMomentum, normal)), c)
+}
+
+// comNormal returns the contact normal in the centre of momentum frame, given the momenta of the particles in that
+// frame and the normal in the original frame.
+//
+// The particles touch at the same time in the original frame, which are two different times in the centre of momentum
+// frame. Both positions are boosted as events and moved along their worldlines to the time halfway between them, and
+// the normal is the direction between them at that time. Only the direction matters, so q is put at the origin and p
+// one unit along the normal, which also keeps the normal the topology chose across its seams.
+func comNormal(CoM Boost, pMomentum, qMomentum FourMomentum, normal Vector, c float64) Vector {
+	pEvent := CoM.Event(FourVector{X: normal.X, Y: normal.Y})
+	qEvent := FourVector{}
+
+	t := (pEvent.T + qEvent.T) / 2
+	pPos := pEvent.Spatial().Add(pMomentum.Velocity(c).Scl((t - pEvent.T) / c))
+	qPos := qEvent.Spatial().Add(qMomentum.Velocity(c).Scl((t - qEvent.T) / c))
+
+	return pPos.Sub(qPos).Unit()
+}
+
+// reflectNormal returns the four-momentum with the component of its momentum along the unit normal reversed
+func reflectNormal(p FourMomentum, normal Vector) FourMomentum {
+	spatial := p.Spatial()
+	spatial = spatial.Sub(normal.Scl(2 * spatial.Dot(normal)))
+
+	return FourMomentum{FourVector{
+		T: p.T,
+		X: spatial.X,
+		Y: spatial.Y,
+	}}
+}
+
+// collideInelastic performs a relativistic collision between two particles with the given coefficient of restitution,
+// where normal is the unit vector along the collision axis pointing from q to p.
+//
+// In the centre of momentum frame the components of the momenta along the normal are reversed and scaled by the
+// coefficient. The energy of the system in that frame is unchanged, so the rest masses of both particles are scaled up
+// by the same factor until it is conserved.
+func collideInelastic(p, q *Particle, normal Vector, c, restitution float64) {
+	pMomentum := p.FourMomentum(c)
+	qMomentum := q.FourMomentum(c)
+
+	// Get the boost into the centre of momentum frame of the system
+	CoM := NewBoost(pMomentum.Add(qMomentum).Velocity(c), c)
+
+	// Get the momenta of the particles in the centre of momentum frame
+	pMomentum = CoM.Momentum(pMomentum)
+	qMomentum = CoM.Momentum(qMomentum)
+
+	// Get the contact normal in the centre of momentum frame
+	normal = comNormal(CoM, pMomentum, qMomentum, normal, c)
+
+	// Only collide particles that are moving towards each other
+	if pMomentum.Spatial().Sub(qMomentum.Spatial()).Dot(normal) >= 0 {
+		return
+	}
+
+	// Reverse and scale the momenta along the normal
+	pSpatial := pMomentum.Spatial()
+	pSpatial = pSpatial.Sub(normal.Scl((1 + restitution) * pSpatial.Dot(normal)))
+	qSpatial := qMomentum.Spatial()
+	qSpatial = qSpatial.Sub(normal.Scl((1 + restitution) * qSpatial.Dot(normal)))
+
+	// Find the factor to scale the rest masses by, so that the energy in the centre of momentum frame is conserved
+	energy := pMomentum.T + qMomentum.T
+	energyWith := func(scale float64) float64 {
+		return math.Hypot(pSpatial.Mag(), scale*p.Mass*c) + math.Hypot(qSpatial.Mag(), scale*q.Mass*c)
+	}
+
+	low, high := 1.0, energy/((p.Mass+q.Mass)*c)
+	for i := 0; i < 64; i++ {
+		mid := (low + high) / 2
+		if energyWith(mid) < energy {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+
+	p.Mass *= low
+	q.Mass *= low
+
+	// Transform back to the original reference frame
+	p.SetFourMomentum(CoM.Inverse().Momentum(FourMomentum{FourVector{
+		T: math.Hypot(pSpatial.Mag(), p.Mass*c),
+		X: pSpatial.X,
+		Y: pSpatial.Y,
+	}}), c)
+	q.SetFourMomentum(CoM.Inverse().Momentum(FourMomentum{FourVector{
+		T: math.Hypot(qSpatial.Mag(), q.Mass*c),
+		X: qSpatial.X,
+		Y: qSpatial.Y,
+	}}), c)
+}
@@ -0,0 +1,164 @@
+package sim
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// randParticle returns a particle with a random mass and a random velocity slower than c
+func randParticle(rng *rand.Rand, c float64) *Particle {
+	p := &Particle{
+		Mass:   rng.Float64()*10 + 0.1,
+		Radius: 1,
+		Gamma:  1,
+	}
+	p.SetFourMomentum(NewFourMomentum(p.Mass, randVelocity(rng, c), c), c)
+
+	return p
+}
+
+func TestCollideElasticConservesFourMomentum(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 10000; i++ {
+		c := math.Pow(10, rng.Float64()*10-1)
+		p := randParticle(rng, c)
+		q := randParticle(rng, c)
+		normal := randUnit(rng)
+
+		before := p.FourMomentum(c).Add(q.FourMomentum(c))
+		collideElastic(p, q, normal, c)
+		after := p.FourMomentum(c).Add(q.FourMomentum(c))
+
+		// Compare relative to the total energy of the system
+		scale := before.T
+		if !approxEqual(after.T/scale, before.T/scale, 1e3) ||
+			!approxEqual(after.X/scale, before.X/scale, 1e3) ||
+			!approxEqual(after.Y/scale, before.Y/scale, 1e3) {
+			t.Fatalf("four-momentum changed from %v to %v (c = %g)", before, after, c)
+		}
+
+		if p.Vel.Mag() >= c || q.Vel.Mag() >= c {
+			t.Fatalf("collision produced a velocity faster than light (c = %g): %v, %v", c, p.Vel, q.Vel)
+		}
+	}
+}
+
+func TestCollideElasticSeparating(t *testing.T) {
+	c := 10.0
+	p := &Particle{Mass: 1, Vel: Vector{1, 0}}
+	q := &Particle{Mass: 1, Vel: Vector{-1, 0}}
+
+	// p is to the right of q and moving right, so the particles are separating
+	collideElastic(p, q, Vector{1, 0}, c)
+
+	if p.Vel != (Vector{1, 0}) || q.Vel != (Vector{-1, 0}) {
+		t.Fatalf("separating particles collided: %v, %v", p.Vel, q.Vel)
+	}
+}
+
+func TestCollideElasticOblique(t *testing.T) {
+	c := 10.0
+	normal := Vector{1, 0}
+
+	// In the centre of momentum frame p touches q one unit along the normal at time 0, both moving in obliquely
+	momentum := Vector{-0.6, 0.8}.Scl(20)
+	pCoM := FourMomentum{FourVector{T: math.Hypot(momentum.Mag(), 1*c), X: momentum.X, Y: momentum.Y}}
+	qCoM := FourMomentum{FourVector{T: math.Hypot(momentum.Mag(), 3*c), X: -momentum.X, Y: -momentum.Y}}
+
+	// The centre of momentum moves obliquely through the lab at 0.95c
+	frame := Vector{math.Cos(1), math.Sin(1)}.Scl(0.95 * c)
+	toLab := NewBoost(frame, c).Inverse()
+
+	// Events on the worldlines at times tau and -tau in the centre of momentum frame, in the lab
+	pEvent := func(tau float64) FourVector {
+		pos := normal.Add(pCoM.Velocity(c).Scl(tau))
+		return toLab.Event(FourVector{T: c * tau, X: pos.X, Y: pos.Y})
+	}
+	qEvent := func(tau float64) FourVector {
+		pos := qCoM.Velocity(c).Scl(-tau)
+		return toLab.Event(FourVector{T: -c * tau, X: pos.X, Y: pos.Y})
+	}
+
+	// The difference in their lab times is linear in tau, find where they are simultaneous in the lab
+	f0, f1 := pEvent(0).T-qEvent(0).T, pEvent(1).T-qEvent(1).T
+	tau := -f0 / (f1 - f0)
+
+	p := &Particle{Mass: 1, Pos: pEvent(tau).Spatial()}
+	q := &Particle{Mass: 3, Pos: qEvent(tau).Spatial()}
+	p.SetFourMomentum(toLab.Momentum(pCoM), c)
+	q.SetFourMomentum(toLab.Momentum(qCoM), c)
+
+	before := p.FourMomentum(c).Add(q.FourMomentum(c))
+	collideElastic(p, q, p.Pos.Sub(q.Pos).Unit(), c)
+	after := p.FourMomentum(c).Add(q.FourMomentum(c))
+
+	scale := before.T
+	if !approxEqual(after.T/scale, before.T/scale, 1e3) ||
+		!approxEqual(after.X/scale, before.X/scale, 1e3) ||
+		!approxEqual(after.Y/scale, before.Y/scale, 1e3) {
+		t.Fatalf("four-momentum changed from %v to %v", before, after)
+	}
+
+	// In the centre of momentum frame the momentum of p along the normal is reversed and the rest is kept
+	got := NewBoost(frame, c).Momentum(p.FourMomentum(c)).Spatial()
+	want := Vector{-momentum.X, momentum.Y}
+	if !approxEqual(got.X, want.X, 1e3) || !approxEqual(got.Y, want.Y, 1e3) {
+		t.Fatalf("p scattered to %v in the centre of momentum frame, want %v", got, want)
+	}
+}
+
+func TestCollisionModelsConserveFourMomentum(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+
+	for _, model := range []CollisionModel{Elastic{}, Restitution{0.5}, Restitution{0}, Merge{}} {
+		for i := 0; i < 1000; i++ {
+			c := math.Pow(10, rng.Float64()*4)
+			p := randParticle(rng, c)
+			q := randParticle(rng, c)
+
+			// Make sure the particles are approaching along the normal
+			normal := q.Vel.Sub(p.Vel).Unit()
+
+			restMass := p.Mass + q.Mass
+			before := p.FourMomentum(c).Add(q.FourMomentum(c))
+
+			// A merged particle carries the four-momentum of the whole pair
+			merged := model.Collide(p, q, normal, c)
+			after := p.FourMomentum(c)
+			if !merged {
+				after = after.Add(q.FourMomentum(c))
+			}
+
+			scale := before.T
+			if !approxEqual(after.T/scale, before.T/scale, 1e3) ||
+				!approxEqual(after.X/scale, before.X/scale, 1e3) ||
+				!approxEqual(after.Y/scale, before.Y/scale, 1e3) {
+				t.Fatalf("%+v: four-momentum changed from %v to %v (c = %g)", model, before, after, c)
+			}
+
+			// Inelastic collisions turn kinetic energy into rest mass
+			if after.Mass(c) < restMass*(1-1e-9) {
+				t.Fatalf("%+v: rest mass decreased from %f to %f", model, restMass, after.Mass(c))
+			}
+		}
+	}
+}
+
+func TestMergeGainsRestMass(t *testing.T) {
+	c := 1.0
+	p := &Particle{Mass: 1, Radius: 1}
+	q := &Particle{Mass: 1, Radius: 1}
+	p.SetFourMomentum(NewFourMomentum(1, Vector{0.6, 0}, c), c)
+	q.SetFourMomentum(NewFourMomentum(1, Vector{-0.6, 0}, c), c)
+
+	if !(Merge{}).Collide(p, q, Vector{-1, 0}, c) {
+		t.Fatal("particles did not merge")
+	}
+
+	// Each particle has gamma = 1.25, so the merged particle is at rest with the total energy of the pair
+	if !approxEqual(p.Mass, 2.5, 1) || !approxEqual(p.Vel.Mag(), 0, 1) {
+		t.Fatalf("got mass %f and velocity %v, want 2.5 at rest", p.Mass, p.Vel)
+	}
+}
@@ -0,0 +1,347 @@
+package sim
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/charmbracelet/log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Duration is a time.Duration that is stored in config files as a string such as "10s"
+type Duration time.Duration
+
+// MarshalJSON returns the duration as a JSON string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON parses the duration from a JSON string
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"10s\": %w", err)
+	}
+
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(v)
+	return nil
+}
+
+// Seconds returns the duration as a floating point number of seconds
+func (d Duration) Seconds() float64 {
+	return time.Duration(d).Seconds()
+}
+
+// UserConfigPath returns the location of the file with the given name in the game's user config directory
+func UserConfigPath(name string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "relativistic_asteroids", name), nil
+}
+
+// Config holds the settings of the game that can be changed without recompiling
+type Config struct {
+	// Window
+	WindowWidth  int `json:"windowWidth"`  // The width of the window
+	WindowHeight int `json:"windowHeight"` // The height of the window
+
+	// Physics
+	PhysicsRate    int     `json:"physicsRate"`    // How many times the simulation is stepped per second
+	MaxSubsteps    int     `json:"maxSubsteps"`    // The most substeps a step is split into when particles move fast
+	C              float64 `json:"c"`              // The speed of light at the start of a run
+	Drag           float64 `json:"drag"`           // The drag coefficient of the ship
+	Thrust         float64 `json:"thrust"`         // The thrust force of the ship
+	CollisionModel string  `json:"collisionModel"` // How asteroids collide: "elastic", "restitution" or "merge"
+	Restitution    float64 `json:"restitution"`    // The coefficient of restitution for the "restitution" model
+	BroadPhase     string  `json:"broadPhase"`     // How asteroid collisions are found: "brute", "hash" or "sweep"
+	Integrator     string  `json:"integrator"`     // How motion is integrated: "legacy", "verlet" or "rk4"
+	Topology       string  `json:"topology"`       // The shape of space: "unbounded", "ring" or "torus"
+	WorldSize      float64 `json:"worldSize"`      // The radius of the ring, or the length of the sides of the torus
+
+	// Difficulty
+	Asteroids         int      `json:"asteroids"`         // The number of asteroids at the start of a run
+	AsteroidArea      float64  `json:"asteroidArea"`      // The radius of the area the asteroids start in
+	Health            int      `json:"health"`            // The health of the ship at the start of a run
+	Ammo              int      `json:"ammo"`              // The ammo of the ship at the start of a run
+	BulletLifetime    Duration `json:"bulletLifetime"`    // How long bullets last
+	Invincibility     Duration `json:"invincibility"`     // How long the ship is invincible for after being hit
+	SlowdownDivisor   float64  `json:"slowdownDivisor"`   // The speed of light is divided by this when an asteroid is hit
+	SlowdownOffset    float64  `json:"slowdownOffset"`    // This is added to the speed of light when an asteroid is hit
+	SlowdownDuration  Duration `json:"slowdownDuration"`  // How long the speed of light takes to slow down
+	ExplosionLifetime Duration `json:"explosionLifetime"` // How long explosion particles last
+
+	// Waves
+	Waves      bool     `json:"waves"`      // Whether a new wave of asteroids is spawned once every asteroid has been destroyed
+	WaveGrowth int      `json:"waveGrowth"` // How many more asteroids each wave has than the last
+	WaveSpeed  float64  `json:"waveSpeed"`  // How much faster the asteroids of each wave move than the last, as a rapidity
+	WaveDelay  Duration `json:"waveDelay"`  // How long after a wave is cleared the next wave is spawned
+	WaveC      string   `json:"waveC"`      // What happens to the speed of light at each wave: "continue" or "reset"
+	WaveCDecay float64  `json:"waveCDecay"` // When reset, the speed of light at the start of each wave is this times that of the last
+
+	// Rendering
+	Retarded   bool `json:"retarded"`   // Whether particles start drawn at their retarded positions
+	Doppler    bool `json:"doppler"`    // Whether particles start tinted by their doppler shift
+	Aberration bool `json:"aberration"` // Whether particles start drawn at their aberrated positions
+}
+
+// DefaultConfig returns the default settings of the game
+func DefaultConfig() Config {
+	return Config{
+		WindowWidth:  800,
+		WindowHeight: 600,
+
+		PhysicsRate:    60,
+		MaxSubsteps:    8,
+		C:              299792458.0,
+		Drag:           0.1,
+		Thrust:         10,
+		CollisionModel: "elastic",
+		Restitution:    1,
+		BroadPhase:     "hash",
+		Integrator:     "legacy",
+		Topology:       "unbounded",
+		WorldSize:      60,
+
+		Asteroids:         64,
+		AsteroidArea:      20,
+		Health:            3,
+		Ammo:              10,
+		BulletLifetime:    Duration(time.Second * 10),
+		Invincibility:     Duration(time.Second),
+		SlowdownDivisor:   8,
+		SlowdownOffset:    10,
+		SlowdownDuration:  Duration(time.Second * 2),
+		ExplosionLifetime: Duration(time.Second),
+
+		Waves:      true,
+		WaveGrowth: 16,
+		WaveSpeed:  0.5,
+		WaveDelay:  Duration(time.Second * 3),
+		WaveC:      "continue",
+		WaveCDecay: 1,
+
+		Doppler: true,
+	}
+}
+
+// register binds the settings to flags in fs, using the current settings as defaults
+func (c *Config) register(fs *flag.FlagSet) {
+	fs.IntVar(&c.WindowWidth, "width", c.WindowWidth, "width of the window")
+	fs.IntVar(&c.WindowHeight, "height", c.WindowHeight, "height of the window")
+
+	fs.IntVar(&c.PhysicsRate, "physics-rate", c.PhysicsRate, "how many times the simulation is stepped per second")
+	fs.IntVar(&c.MaxSubsteps, "max-substeps", c.MaxSubsteps, "the most substeps a step is split into when particles move fast")
+	fs.Float64Var(&c.C, "c", c.C, "speed of light at the start of a run")
+	fs.Float64Var(&c.Drag, "drag", c.Drag, "drag coefficient of the ship")
+	fs.Float64Var(&c.Thrust, "thrust", c.Thrust, "thrust force of the ship")
+	fs.StringVar(&c.CollisionModel, "collisions", c.CollisionModel, "asteroid collision model: elastic, restitution or merge")
+	fs.Float64Var(&c.Restitution, "restitution", c.Restitution, "coefficient of restitution for the restitution collision model")
+	fs.StringVar(&c.BroadPhase, "broadphase", c.BroadPhase, "asteroid collision broad phase: brute, hash or sweep")
+	fs.StringVar(&c.Integrator, "integrator", c.Integrator, "motion integrator: legacy, verlet or rk4")
+	fs.StringVar(&c.Topology, "topology", c.Topology, "shape of space: unbounded, ring or torus")
+	fs.Float64Var(&c.WorldSize, "world-size", c.WorldSize, "radius of the ring, or length of the sides of the torus")
+
+	fs.IntVar(&c.Asteroids, "asteroids", c.Asteroids, "number of asteroids at the start of a run")
+	fs.Float64Var(&c.AsteroidArea, "area", c.AsteroidArea, "radius of the area the asteroids start in")
+	fs.IntVar(&c.Health, "health", c.Health, "health of the ship at the start of a run")
+	fs.IntVar(&c.Ammo, "ammo", c.Ammo, "ammo of the ship at the start of a run")
+	fs.DurationVar((*time.Duration)(&c.BulletLifetime), "bullet-lifetime", time.Duration(c.BulletLifetime), "how long bullets last")
+	fs.DurationVar((*time.Duration)(&c.Invincibility), "invincibility", time.Duration(c.Invincibility), "how long the ship is invincible for after being hit")
+	fs.Float64Var(&c.SlowdownDivisor, "slowdown-divisor", c.SlowdownDivisor, "the speed of light is divided by this when an asteroid is hit")
+	fs.Float64Var(&c.SlowdownOffset, "slowdown-offset", c.SlowdownOffset, "this is added to the speed of light when an asteroid is hit")
+	fs.DurationVar((*time.Duration)(&c.SlowdownDuration), "slowdown-duration", time.Duration(c.SlowdownDuration), "how long the speed of light takes to slow down")
+	fs.DurationVar((*time.Duration)(&c.ExplosionLifetime), "explosion-lifetime", time.Duration(c.ExplosionLifetime), "how long explosion particles last")
+
+	fs.BoolVar(&c.Waves, "waves", c.Waves, "spawn a new wave of asteroids once every asteroid has been destroyed")
+	fs.IntVar(&c.WaveGrowth, "wave-growth", c.WaveGrowth, "how many more asteroids each wave has than the last")
+	fs.Float64Var(&c.WaveSpeed, "wave-speed", c.WaveSpeed, "how much faster the asteroids of each wave move than the last, as a rapidity")
+	fs.DurationVar((*time.Duration)(&c.WaveDelay), "wave-delay", time.Duration(c.WaveDelay), "how long after a wave is cleared the next wave is spawned")
+	fs.StringVar(&c.WaveC, "wave-c", c.WaveC, "what happens to the speed of light at each wave: continue or reset")
+	fs.Float64Var(&c.WaveCDecay, "wave-c-decay", c.WaveCDecay, "when reset, the speed of light at the start of each wave is this times that of the last")
+
+	fs.BoolVar(&c.Retarded, "retarded", c.Retarded, "draw particles at their retarded positions")
+	fs.BoolVar(&c.Doppler, "doppler", c.Doppler, "tint particles by their doppler shift")
+	fs.BoolVar(&c.Aberration, "aberration", c.Aberration, "draw particles at their aberrated positions")
+}
+
+// load reads the settings from the JSON file at path, settings missing from the file are left unchanged
+func (c *Config) load(path string) error {
+	log.Debug("loading config", "path", path)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(c); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// ParseConfig registers the config flags on fs and parses args. If a config file is given with -config it is loaded
+// first, so that flags given on the command line override it. The resulting config is validated.
+func ParseConfig(fs *flag.FlagSet, args []string) (Config, error) {
+	cfg := DefaultConfig()
+	path := fs.String("config", "", "load settings from a JSON config file")
+	cfg.register(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	if *path != "" {
+		file := DefaultConfig()
+		if err := file.load(*path); err != nil {
+			return cfg, err
+		}
+
+		// Apply the flags that were set on the command line on top of the file
+		overrides := flag.NewFlagSet("", flag.ContinueOnError)
+		file.register(overrides)
+
+		var err error
+		fs.Visit(func(f *flag.Flag) {
+			if err == nil && overrides.Lookup(f.Name) != nil {
+				err = overrides.Set(f.Name, f.Value.String())
+			}
+		})
+
+		if err != nil {
+			return cfg, err
+		}
+
+		cfg = file
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// Validate returns an error describing every invalid setting, or nil if the config is valid
+func (c Config) Validate() error {
+	var errs []error
+
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.WindowWidth > 0 && c.WindowHeight > 0, "window size must be positive, got %dx%d", c.WindowWidth, c.WindowHeight)
+
+	check(c.PhysicsRate >= 10 && c.PhysicsRate <= 1000, "physics rate must be between 10 and 1000, got %d", c.PhysicsRate)
+	check(c.MaxSubsteps >= 1, "max substeps must be at least 1, got %d", c.MaxSubsteps)
+	check(c.C > 0, "speed of light must be positive, got %g", c.C)
+	check(c.Drag >= 0, "drag must not be negative, got %g", c.Drag)
+	check(c.Thrust >= 0, "thrust must not be negative, got %g", c.Thrust)
+	check(c.Restitution >= 0 && c.Restitution <= 1, "restitution must be between 0 and 1, got %g", c.Restitution)
+
+	if _, err := c.collisionModel(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if _, err := c.broadPhase(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if _, err := c.integrator(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if _, err := c.topology(); err != nil {
+		errs = append(errs, err)
+	}
+
+	check(c.WorldSize > 0, "world size must be positive, got %g", c.WorldSize)
+
+	check(c.Asteroids >= 0, "number of asteroids must not be negative, got %d", c.Asteroids)
+	check(c.AsteroidArea > 0, "asteroid area must be positive, got %g", c.AsteroidArea)
+	check(c.Health > 0, "health must be positive, got %d", c.Health)
+	check(c.Ammo > 0, "ammo must be positive, got %d", c.Ammo)
+	check(c.BulletLifetime > 0, "bullet lifetime must be positive, got %s", time.Duration(c.BulletLifetime))
+	check(c.Invincibility >= 0, "invincibility must not be negative, got %s", time.Duration(c.Invincibility))
+	check(c.SlowdownDivisor >= 1, "slowdown divisor must be at least 1, got %g", c.SlowdownDivisor)
+	check(c.SlowdownOffset > 0, "slowdown offset must be positive, got %g", c.SlowdownOffset)
+	check(c.SlowdownDuration > 0, "slowdown duration must be positive, got %s", time.Duration(c.SlowdownDuration))
+	check(c.ExplosionLifetime > 0, "explosion lifetime must be positive, got %s", time.Duration(c.ExplosionLifetime))
+
+	check(c.WaveGrowth >= 0, "wave growth must not be negative, got %d", c.WaveGrowth)
+	check(c.WaveSpeed >= 0, "wave speed must not be negative, got %g", c.WaveSpeed)
+	check(c.WaveDelay >= 0, "wave delay must not be negative, got %s", time.Duration(c.WaveDelay))
+	check(c.WaveC == "continue" || c.WaveC == "reset", "unknown wave speed of light %q, must be continue or reset", c.WaveC)
+	check(c.WaveCDecay > 0 && c.WaveCDecay <= 1, "wave c decay must be between 0 and 1, got %g", c.WaveCDecay)
+
+	return errors.Join(errs...)
+}
+
+// collisionModel returns the collision model named in the config
+func (c Config) collisionModel() (CollisionModel, error) {
+	switch c.CollisionModel {
+	case "elastic":
+		return Elastic{}, nil
+	case "restitution":
+		return Restitution{c.Restitution}, nil
+	case "merge":
+		return Merge{}, nil
+	default:
+		return nil, fmt.Errorf("unknown collision model %q, must be elastic, restitution or merge", c.CollisionModel)
+	}
+}
+
+// broadPhase returns the broad phase named in the config
+func (c Config) broadPhase() (BroadPhase, error) {
+	switch c.BroadPhase {
+	case "brute":
+		return BruteForce{}, nil
+	case "hash":
+		return SpatialHash{}, nil
+	case "sweep":
+		return SweepAndPrune{}, nil
+	default:
+		return nil, fmt.Errorf("unknown broad phase %q, must be brute, hash or sweep", c.BroadPhase)
+	}
+}
+
+// integrator returns the integrator named in the config
+func (c Config) integrator() (Integrator, error) {
+	switch c.Integrator {
+	case "legacy":
+		return Legacy{}, nil
+	case "verlet":
+		return VelocityVerlet{}, nil
+	case "rk4":
+		return RK4{}, nil
+	default:
+		return nil, fmt.Errorf("unknown integrator %q, must be legacy, verlet or rk4", c.Integrator)
+	}
+}
+
+// topology returns the topology named in the config
+func (c Config) topology() (Topology, error) {
+	switch c.Topology {
+	case "unbounded":
+		return Unbounded{}, nil
+	case "ring":
+		return SpawnRing{Radius: c.WorldSize}, nil
+	case "torus":
+		return Torus{Size: c.WorldSize}, nil
+	default:
+		return nil, fmt.Errorf("unknown topology %q, must be unbounded, ring or torus", c.Topology)
+	}
+}
@@ -0,0 +1,71 @@
+package sim
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseConfigFlagsOverrideFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"asteroids": 10, "ammo": 5, "bulletLifetime": "3s"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg, err := ParseConfig(fs, []string{"-ammo", "20", "-config", path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Asteroids != 10 || time.Duration(cfg.BulletLifetime) != time.Second*3 {
+		t.Fatalf("settings from the file were not applied: %+v", cfg)
+	}
+
+	if cfg.Ammo != 20 {
+		t.Fatalf("got ammo %d, want the flag to override the file", cfg.Ammo)
+	}
+
+	if cfg.Health != DefaultConfig().Health {
+		t.Fatalf("got health %d, want the default", cfg.Health)
+	}
+}
+
+func TestParseConfigRejectsUnknownFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"asteroid": 10}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if _, err := ParseConfig(fs, []string{"-config", path}); err == nil {
+		t.Fatal("expected an error for a misspelled setting")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Fatalf("default config is invalid: %v", err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.C = 0
+	cfg.Health = -1
+	cfg.CollisionModel = "sticky"
+	cfg.Topology = "sphere"
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected an invalid config")
+	}
+
+	// Every problem is reported at once
+	for _, want := range []string{"speed of light", "health", "sticky", "sphere"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
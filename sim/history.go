@@ -0,0 +1,171 @@
+package sim
+
+// HistorySample is the state of a particle at a moment in simulation time
+type HistorySample struct {
+	Time   float64 // The simulation time at which the sample was taken
+	Pos    Vector  // Position of the particle
+	Vel    Vector  // Velocity of the particle
+	AngPos float64 // Angular position of the particle
+	Clock  float64 // Time measured by a clock on the particle
+}
+
+// Sample returns the current state of the particle as a history sample taken at time t
+func (p *Particle) Sample(t float64) HistorySample {
+	return HistorySample{
+		Time:   t,
+		Pos:    p.Pos,
+		Vel:    p.Vel,
+		AngPos: p.AngPos,
+		Clock:  p.Clock,
+	}
+}
+
+// Apply returns a copy of the particle in the state of the sample
+func (s HistorySample) Apply(p *Particle) *Particle {
+	out := *p
+	out.Pos = s.Pos
+	out.Vel = s.Vel
+	out.AngPos = s.AngPos
+	out.Clock = s.Clock
+	out.SavePrevious()
+
+	return &out
+}
+
+// lerpSample linearly interpolates between two samples by a factor x
+func lerpSample(a, b HistorySample, x float64) HistorySample {
+	return HistorySample{
+		Time:   a.Time + (b.Time-a.Time)*x,
+		Pos:    a.Pos.Add(b.Pos.Sub(a.Pos).Scl(x)),
+		Vel:    a.Vel.Add(b.Vel.Sub(a.Vel).Scl(x)),
+		AngPos: a.AngPos + (b.AngPos-a.AngPos)*x,
+		Clock:  a.Clock + (b.Clock-a.Clock)*x,
+	}
+}
+
+// History is a fixed length record of the most recent states of a particle
+type History struct {
+	samples []HistorySample // Ring buffer of samples
+	start   int             // The index of the oldest sample in the ring buffer
+	n       int             // The number of samples recorded
+}
+
+// NewHistory returns a new history that keeps the n most recent samples
+func NewHistory(n int) *History {
+	return &History{
+		samples: make([]HistorySample, n),
+	}
+}
+
+// Record adds a sample to the history, replacing the oldest sample if the history is full
+func (h *History) Record(s HistorySample) {
+	if h.n < len(h.samples) {
+		h.samples[(h.start+h.n)%len(h.samples)] = s
+		h.n++
+		return
+	}
+
+	h.samples[h.start] = s
+	h.start = (h.start + 1) % len(h.samples)
+}
+
+// Len returns the number of samples in the history
+func (h *History) Len() int {
+	return h.n
+}
+
+// At returns the i-th oldest sample in the history
+func (h *History) At(i int) HistorySample {
+	return h.samples[(h.start+i)%len(h.samples)]
+}
+
+// Shift moves every sample in the history by d
+func (h *History) Shift(d Vector) {
+	for i := 0; i < h.n; i++ {
+		j := (h.start + i) % len(h.samples)
+		h.samples[j].Pos = h.samples[j].Pos.Add(d)
+	}
+}
+
+// Retarded returns the state of the particle that an observer at obs sees at time t, which is the state when the
+// light reaching the observer left the particle. This is the time t_ret that satisfies |obs − x(t_ret)| = c·(t − t_ret),
+// interpolated between samples. If the history does not go back far enough, the oldest sample is returned and ok is
+// false.
+func (h *History) Retarded(obs Vector, t, c float64) (s HistorySample, ok bool) {
+	if h.n == 0 {
+		return HistorySample{}, false
+	}
+
+	// lag is positive while light from the sample has not yet reached the observer
+	lag := func(s HistorySample) float64 {
+		return obs.Dist(s.Pos) - c*(t-s.Time)
+	}
+
+	// Search backwards in time for the first sample whose light has already reached the observer
+	newer := h.At(h.n - 1)
+	newerLag := lag(newer)
+
+	if newerLag <= 0 {
+		return newer, true
+	}
+
+	for i := h.n - 2; i >= 0; i-- {
+		older := h.At(i)
+		olderLag := lag(older)
+
+		if olderLag <= 0 {
+			// Interpolate to where the lag crosses zero
+			return lerpSample(older, newer, olderLag/(olderLag-newerLag)), true
+		}
+
+		newer, newerLag = older, olderLag
+	}
+
+	return newer, false
+}
+
+// Simultaneous returns the state of the particle at the moment an observer at obs moving with velocity frame considers
+// simultaneous with its own time t. This is the time t_s that satisfies t_s = t + frame·(x(t_s) − obs)/c², so the
+// particle is seen later than t when it is ahead of the observer and earlier when it is behind. States between samples
+// are interpolated and states after the newest sample are extrapolated from the two newest samples. If the history
+// does not go back far enough, the oldest sample is returned and ok is false.
+func (h *History) Simultaneous(obs, frame Vector, t, c float64) (s HistorySample, ok bool) {
+	// At least two samples are needed to extrapolate
+	if h.n == 0 {
+		return HistorySample{}, false
+	}
+
+	if h.n == 1 {
+		return h.At(0), false
+	}
+
+	// lag is positive for samples after the hyperplane of simultaneity of the observer
+	lag := func(s HistorySample) float64 {
+		return s.Time - t - frame.Dot(s.Pos.Sub(obs))/(c*c)
+	}
+
+	// Extrapolate along the two newest samples if the hyperplane is after the newest sample
+	newer := h.At(h.n - 1)
+	newerLag := lag(newer)
+
+	if newerLag <= 0 {
+		older := h.At(h.n - 2)
+		olderLag := lag(older)
+
+		return lerpSample(older, newer, olderLag/(olderLag-newerLag)), true
+	}
+
+	// Otherwise search backwards in time for the first sample before the hyperplane
+	for i := h.n - 2; i >= 0; i-- {
+		older := h.At(i)
+		olderLag := lag(older)
+
+		if olderLag <= 0 {
+			return lerpSample(older, newer, olderLag/(olderLag-newerLag)), true
+		}
+
+		newer, newerLag = older, olderLag
+	}
+
+	return newer, false
+}
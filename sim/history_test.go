@@ -0,0 +1,91 @@
+package sim
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHistoryRetarded(t *testing.T) {
+	c := 10.0
+	v := Vector{3, 0}
+	dt := 1.0 / 60
+	h := NewHistory(int(5/dt) + 1)
+
+	// Record a particle moving at constant velocity through the origin at t = 0
+	var now float64
+	for i := 0; i <= int(4/dt); i++ {
+		now = float64(i) * dt
+		h.Record(HistorySample{Time: now, Pos: v.Scl(now - 2)})
+	}
+
+	// An observer at obs sees the particle where |obs − v·(t_ret − 2)| = c·(now − t_ret)
+	obs := Vector{0, 20}
+	s, ok := h.Retarded(obs, now, c)
+	if !ok {
+		t.Fatal("history did not go back far enough")
+	}
+
+	if lag := obs.Dist(s.Pos) - c*(now-s.Time); math.Abs(lag) > 1e-3 {
+		t.Fatalf("retarded sample at t = %f is off the light cone by %f", s.Time, lag)
+	}
+
+	if !approxEqual(s.Pos.X, v.X*(s.Time-2), 1) {
+		t.Fatalf("retarded position %v is not on the worldline at t = %f", s.Pos, s.Time)
+	}
+
+	// With a very fast speed of light the particle is seen where it is now
+	s, _ = h.Retarded(obs, now, 1e12)
+	if math.Abs(s.Time-now) > 1e-6 {
+		t.Fatalf("got retarded time %f, want %f", s.Time, now)
+	}
+
+	// The light from a far away particle has not had time to arrive
+	if _, ok := h.Retarded(Vector{0, 1000}, now, c); ok {
+		t.Fatal("expected the history to be too short")
+	}
+}
+
+func TestHistorySimultaneous(t *testing.T) {
+	c := 10.0
+	v := Vector{0, 4}
+	dt := 1.0 / 60
+	h := NewHistory(int(5/dt) + 1)
+
+	// Record a particle moving at constant velocity with a clock running slow by its lorentz factor
+	var now float64
+	for i := 0; i <= int(4/dt); i++ {
+		now = float64(i) * dt
+		h.Record(HistorySample{Time: now, Pos: Vector{0, -10}.Add(v.Scl(now)), Clock: now / Gamma(v.Mag(), c)})
+	}
+
+	frame := Vector{6, 0}
+	for _, obs := range []Vector{{-20, 0}, {20, 0}, {0, 0}} {
+		s, ok := h.Simultaneous(obs, frame, now, c)
+		if !ok {
+			t.Fatalf("observer at %v: history did not go back far enough", obs)
+		}
+
+		if lag := s.Time - now - frame.Dot(s.Pos.Sub(obs))/(c*c); math.Abs(lag) > 1e-9 {
+			t.Fatalf("observer at %v: sample at t = %f is off the hyperplane of simultaneity by %g", obs, s.Time, lag)
+		}
+
+		// Extrapolated samples stay on the worldline
+		if !approxEqual(s.Pos.Y, -10+v.Y*s.Time, 1) || !approxEqual(s.Clock, s.Time/Gamma(v.Mag(), c), 1) {
+			t.Fatalf("observer at %v: sample %+v is not on the worldline", obs, s)
+		}
+	}
+
+	// The particle is at x = 0, which is ahead of the observer at x = -20 and behind the observer at x = 20, so the
+	// first observer considers a later state of the particle simultaneous than the second
+	behind, _ := h.Simultaneous(Vector{-20, 0}, frame, now, c)
+	ahead, _ := h.Simultaneous(Vector{20, 0}, frame, now, c)
+
+	if behind.Time <= now || ahead.Time >= now || behind.Clock <= ahead.Clock {
+		t.Fatalf("got times %f behind and %f ahead at %f", behind.Time, ahead.Time, now)
+	}
+
+	// At rest the observer sees the particle as it is now
+	if s, _ := h.Simultaneous(Vector{20, 0}, Vector{}, now, c); !approxEqual(s.Time, now, 1) {
+		t.Fatalf("got time %f for an observer at rest, want %f", s.Time, now)
+	}
+}
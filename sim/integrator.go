@@ -0,0 +1,134 @@
+package sim
+
+import "math"
+
+// Integrator advances the motion of a particle under a force over a step of simulation time
+type Integrator interface {
+	// Integrate advances the particle by dt seconds of simulation time under a constant force. The force is the proper
+	// force, felt by the particle in its own rest frame.
+	Integrate(p *Particle, force, frame Vector, c, dt float64)
+
+	// Speed returns how fast the particle moves through the simulation when integrated, used to decide how finely to
+	// split a step
+	Speed(p *Particle, frame Vector, c float64) float64
+}
+
+// Legacy is the original integration scheme of the game. It scales the step by the lorentz factor relative to the
+// frame, and advances the position using the rapidity rather than the velocity, so trajectories are only
+// qualitatively right.
+type Legacy struct{}
+
+// Integrate advances the particle using the original scheme
+func (Legacy) Integrate(p *Particle, force, frame Vector, c, dt float64) {
+	dtr := dt * p.Gamma // Get Δt adjusted by lorentz factor
+
+	p.Clock += dtr // Add Δt to clock
+
+	p.AngPos += p.AngVel * dtr // Update angular position
+
+	// Calculate new properties of the particle
+	acc := force.Scl(1 / p.Mass)                                   // Calculate acceleration from force
+	rap := p.Rap.Add(p.Acc.Add(acc).Scl(dtr / 2))                  // Update rapidity from acceleration
+	vel := p.Rap.SetMag(c * math.Tanh(p.Rap.Mag()/c))              // Calculate velocity from rapidity
+	pos := p.Pos.Add(p.Rap.Scl(dtr)).Add(p.Acc.Scl(dtr * dtr / 2)) // Calculate new position with respect to acceleration and rapidity
+
+	// Update all variables
+	p.Acc = acc
+	p.Rap = rap
+	p.Vel = vel
+	p.Pos = pos
+}
+
+// Speed returns the rapidity scaled by the lorentz factor relative to the frame, as used by Integrate
+func (Legacy) Speed(p *Particle, frame Vector, c float64) float64 {
+	return p.Rap.Mag() * Gamma(p.FrameVelocity(frame, c).Mag(), c)
+}
+
+// VelocityVerlet integrates the motion in the proper time of the particle with a velocity Verlet scheme, keeping
+// the proper velocity as its state. It is second order accurate.
+type VelocityVerlet struct{}
+
+// Integrate advances the particle by half a kick, a drift and a full kick, all in proper time. The proper
+// acceleration depends on the velocity, so the full kick uses the acceleration halfway through the step to stay second
+// order.
+func (VelocityVerlet) Integrate(p *Particle, force, frame Vector, c, dt float64) {
+	acc := force.Scl(1 / p.Mass)
+	u := p.ProperVelocity(c)
+
+	// The proper time of the step depends on the lorentz factor halfway through the step, so estimate it from a
+	// first half kick and then redo the half kick with the better estimate
+	dtau := dt / properGamma(u, c)
+	half := u.Add(properAcceleration(acc, u, c).Scl(dtau / 2))
+	dtau = dt / properGamma(half, c)
+	half = u.Add(properAcceleration(acc, u, c).Scl(dtau / 2))
+
+	p.Pos = p.Pos.Add(half.Scl(dtau))
+	u = u.Add(properAcceleration(acc, half, c).Scl(dtau))
+
+	p.Clock += dtau
+	p.AngPos += p.AngVel * dtau
+	p.Acc = acc
+	p.SetProperVelocity(u, c)
+}
+
+// Speed returns the speed of the particle
+func (VelocityVerlet) Speed(p *Particle, _ Vector, _ float64) float64 {
+	return p.Vel.Mag()
+}
+
+// RK4 integrates the motion in simulation time with the classic fourth order Runge-Kutta method, keeping the
+// position, proper velocity and proper time as its state.
+type RK4 struct{}
+
+// Integrate advances the particle with four evaluations of the equations of motion
+func (RK4) Integrate(p *Particle, force, frame Vector, c, dt float64) {
+	acc := force.Scl(1 / p.Mass)
+
+	// derivative returns the rate of change of the position, proper velocity and proper time with simulation time
+	derivative := func(u Vector) (dx, du Vector, dtau float64) {
+		gamma := properGamma(u, c)
+		return u.Scl(1 / gamma), properAcceleration(acc, u, c).Scl(1 / gamma), 1 / gamma
+	}
+
+	u := p.ProperVelocity(c)
+
+	x1, u1, t1 := derivative(u)
+	x2, u2, t2 := derivative(u.Add(u1.Scl(dt / 2)))
+	x3, u3, t3 := derivative(u.Add(u2.Scl(dt / 2)))
+	x4, u4, t4 := derivative(u.Add(u3.Scl(dt)))
+
+	// sum returns the weighted sum of the four evaluations
+	sum := func(k1, k2, k3, k4 Vector) Vector {
+		return k1.Add(k2.Scl(2)).Add(k3.Scl(2)).Add(k4).Scl(dt / 6)
+	}
+
+	dtau := (t1 + 2*t2 + 2*t3 + t4) * dt / 6
+
+	p.Pos = p.Pos.Add(sum(x1, x2, x3, x4))
+	p.Clock += dtau
+	p.AngPos += p.AngVel * dtau
+	p.Acc = acc
+	p.SetProperVelocity(u.Add(sum(u1, u2, u3, u4)), c)
+}
+
+// Speed returns the speed of the particle
+func (RK4) Speed(p *Particle, _ Vector, _ float64) float64 {
+	return p.Vel.Mag()
+}
+
+// properGamma returns the lorentz factor of a particle with proper velocity u
+func properGamma(u Vector, c float64) float64 {
+	return math.Sqrt(1 + u.SqrMag()/(c*c))
+}
+
+// properAcceleration returns the rate of change of the proper velocity u with proper time, for a particle that feels
+// the acceleration a in its rest frame. This is the spatial part of the four-acceleration, boosted out of the rest
+// frame: the component of a along the velocity is stretched by the lorentz factor.
+func properAcceleration(a, u Vector, c float64) Vector {
+	if u.SqrMag() == 0 {
+		return a
+	}
+
+	n := u.Unit()
+	return a.Add(n.Scl((properGamma(u, c) - 1) * a.Dot(n)))
+}
@@ -0,0 +1,103 @@
+package sim
+
+import (
+	"math"
+	"testing"
+)
+
+// hyperbolic returns the analytic position, velocity and proper time of a particle starting at rest at the origin
+// after t seconds of constant proper acceleration a along the x axis
+func hyperbolic(a, c, t float64) (pos, vel Vector, tau float64) {
+	gamma := math.Sqrt(1 + (a*t/c)*(a*t/c))
+
+	pos = Vector{c * c / a * (gamma - 1), 0}
+	vel = Vector{a * t / gamma, 0}
+	tau = c / a * math.Asinh(a*t/c)
+
+	return pos, vel, tau
+}
+
+// accelerate integrates a particle starting at rest under a constant proper force for the given number of seconds
+func accelerate(integrator Integrator, force Vector, c, dt, seconds float64) *Particle {
+	p := &Particle{Mass: 2, Gamma: 1}
+	for i := 0; i < int(math.Round(seconds/dt)); i++ {
+		p.Update(force, Vector{}, c, dt, integrator)
+	}
+
+	return p
+}
+
+// hyperbolicError returns the largest relative error in the position, velocity and proper time of a particle
+// integrated under constant proper acceleration
+func hyperbolicError(integrator Integrator, dt float64) float64 {
+	const (
+		c       = 10.0
+		a       = 5.0
+		seconds = 8.0
+	)
+
+	p := accelerate(integrator, Vector{a * 2, 0}, c, dt, seconds)
+	pos, vel, tau := hyperbolic(a, c, seconds)
+
+	return max(
+		p.Pos.Dist(pos)/pos.Mag(),
+		p.Vel.Dist(vel)/vel.Mag(),
+		math.Abs(p.Clock-tau)/tau,
+	)
+}
+
+func TestIntegratorHyperbolicMotion(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		integrator Integrator
+		tolerance  float64
+	}{
+		{"verlet", VelocityVerlet{}, 1e-4},
+		{"rk4", RK4{}, 1e-8},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := hyperbolicError(tc.integrator, 1.0/60); err > tc.tolerance {
+				t.Fatalf("relative error %g from the analytic trajectory, want at most %g", err, tc.tolerance)
+			}
+		})
+	}
+}
+
+func TestIntegratorOrder(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		integrator Integrator
+		order      float64
+	}{
+		{"verlet", VelocityVerlet{}, 2},
+		{"rk4", RK4{}, 4},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			coarse := hyperbolicError(tc.integrator, 1.0/10)
+			fine := hyperbolicError(tc.integrator, 1.0/20)
+
+			// Halving the step should divide the error by about 2^order
+			if order := math.Log2(coarse / fine); order < tc.order-0.5 {
+				t.Fatalf("error fell from %g to %g when halving the step, order %.2f, want %g", coarse, fine, order, tc.order)
+			}
+		})
+	}
+}
+
+func TestIntegratorSlowerThanLight(t *testing.T) {
+	c := 10.0
+
+	for _, integrator := range []Integrator{VelocityVerlet{}, RK4{}} {
+		// A force strong enough to reach a lorentz factor in the thousands
+		p := accelerate(integrator, Vector{30, 40}, c, 1.0/60, 60)
+
+		if p.Vel.Mag() >= c {
+			t.Fatalf("%T reached speed %g, faster than c = %g", integrator, p.Vel.Mag(), c)
+		}
+
+		// The velocity stays along the force
+		if math.Abs(p.Vel.Unit().Dot(Vector{0.6, 0.8})-1) > 1e-9 {
+			t.Fatalf("%T turned the velocity to %v", integrator, p.Vel)
+		}
+	}
+}
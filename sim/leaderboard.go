@@ -0,0 +1,139 @@
+package sim
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/charmbracelet/log"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Defining the leaderboard limits.
+const (
+	// The number of entries kept on the leaderboard
+	leaderboardSize = 10
+
+	// The maximum length of a player name
+	MaxNameLength = 12
+)
+
+// LeaderboardEntry is a single run on the leaderboard
+type LeaderboardEntry struct {
+	Name     string    `json:"name"`     // The name the player entered
+	Score    int       `json:"score"`    // The score of the run
+	Date     time.Time `json:"date"`     // When the run ended
+	C        float64   `json:"c"`        // The speed of light at the end of the run
+	MaxGamma float64   `json:"maxGamma"` // The highest lorentz factor the ship reached during the run
+}
+
+// Leaderboard is the table of the best runs, sorted from the highest score to the lowest
+type Leaderboard struct {
+	Entries []LeaderboardEntry `json:"entries"`
+
+	path string // Where the leaderboard is saved, empty if it is not saved
+}
+
+// LoadLeaderboard reads the leaderboard from the file at path. A missing file gives an empty leaderboard, as does a
+// corrupted one, which is overwritten the next time the leaderboard is saved.
+func LoadLeaderboard(path string) *Leaderboard {
+	log.Debug("loading leaderboard", "path", path)
+
+	l := &Leaderboard{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Debug("no leaderboard file, starting with an empty leaderboard")
+		return l
+	} else if err != nil {
+		log.Warn("failed to read leaderboard, starting with an empty leaderboard", "path", path, "error", err)
+		return l
+	}
+
+	if err := json.Unmarshal(data, l); err != nil {
+		log.Warn("leaderboard is corrupted, starting with an empty leaderboard", "path", path, "error", err)
+		l.Entries = nil
+		return l
+	}
+
+	// Don't trust the file to be sorted or the right length
+	l.sort()
+
+	return l
+}
+
+// sort sorts the entries from the highest score to the lowest and drops any beyond the size of the leaderboard
+func (l *Leaderboard) sort() {
+	sort.SliceStable(l.Entries, func(i, j int) bool {
+		return l.Entries[i].Score > l.Entries[j].Score
+	})
+
+	if len(l.Entries) > leaderboardSize {
+		l.Entries = l.Entries[:leaderboardSize]
+	}
+}
+
+// Best returns the highest score on the leaderboard, or 0 if it is empty
+func (l *Leaderboard) Best() int {
+	if len(l.Entries) == 0 {
+		return 0
+	}
+
+	return l.Entries[0].Score
+}
+
+// Qualifies returns whether a run with the given score would make it onto the leaderboard
+func (l *Leaderboard) Qualifies(score int) bool {
+	if score <= 0 {
+		return false
+	}
+
+	return len(l.Entries) < leaderboardSize || score > l.Entries[len(l.Entries)-1].Score
+}
+
+// Add adds an entry to the leaderboard and returns its rank, starting from 0, or -1 if it did not qualify
+func (l *Leaderboard) Add(e LeaderboardEntry) int {
+	if !l.Qualifies(e.Score) {
+		return -1
+	}
+
+	// Insert after any entries with the same score, so that older runs keep their place
+	rank := sort.Search(len(l.Entries), func(i int) bool {
+		return l.Entries[i].Score < e.Score
+	})
+
+	l.Entries = append(l.Entries, LeaderboardEntry{})
+	copy(l.Entries[rank+1:], l.Entries[rank:])
+	l.Entries[rank] = e
+	l.sort()
+
+	return rank
+}
+
+// Save writes the leaderboard to its file, creating the directory if needed
+func (l *Leaderboard) Save() error {
+	if l.path == "" {
+		return nil
+	}
+
+	log.Debug("saving leaderboard", "path", l.path, "entries", len(l.Entries))
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that a crash can't leave a half written leaderboard
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, l.path)
+}
@@ -0,0 +1,71 @@
+package sim
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLeaderboardAdd(t *testing.T) {
+	l := &Leaderboard{}
+
+	for i := 1; i <= leaderboardSize; i++ {
+		if rank := l.Add(LeaderboardEntry{Score: i * 10}); rank != 0 {
+			t.Fatalf("got rank %d for a new best score, want 0", rank)
+		}
+	}
+
+	if l.Qualifies(10) {
+		t.Fatal("a score equal to the lowest on a full leaderboard should not qualify")
+	}
+
+	// A tied score goes after the existing entry
+	if rank := l.Add(LeaderboardEntry{Name: "tie", Score: 50}); rank != 6 {
+		t.Fatalf("got rank %d, want 6", rank)
+	}
+
+	if len(l.Entries) != leaderboardSize || l.Entries[len(l.Entries)-1].Score != 20 {
+		t.Fatalf("the lowest entry was not dropped: %+v", l.Entries)
+	}
+}
+
+func TestLeaderboardSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "leaderboard.json")
+
+	l := LoadLeaderboard(path)
+	if len(l.Entries) != 0 {
+		t.Fatal("a missing leaderboard should be empty")
+	}
+
+	l.Add(LeaderboardEntry{Name: "a", Score: 3, C: 12, MaxGamma: 1.5})
+	if err := l.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := LoadLeaderboard(path)
+	if len(loaded.Entries) != 1 || loaded.Entries[0] != l.Entries[0] {
+		t.Fatalf("got %+v, want %+v", loaded.Entries, l.Entries)
+	}
+}
+
+func TestLeaderboardCorrupted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leaderboard.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	l := LoadLeaderboard(path)
+	if len(l.Entries) != 0 {
+		t.Fatal("a corrupted leaderboard should be empty")
+	}
+
+	// The corrupted file is replaced when the leaderboard is saved
+	l.Add(LeaderboardEntry{Name: "a", Score: 1})
+	if err := l.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(LoadLeaderboard(path).Entries) != 1 {
+		t.Fatal("the leaderboard was not saved over the corrupted file")
+	}
+}
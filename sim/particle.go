@@ -0,0 +1,181 @@
+package sim
+
+import (
+	"fmt"
+	"math"
+)
+
+// Particle is a particle in the simulation
+type Particle struct {
+	Pos Vector // Position of the particle
+	Rap Vector // Rapidity of the particle
+	Vel Vector // Velocity of the particle
+	Acc Vector // Acceleration of the particle
+
+	AngPos float64 // Angular position of the particle
+	AngVel float64 // Angular velocity of the particle
+
+	Mass   float64 // Mass of the particle
+	Radius float64 // Radius of the particle
+
+	Gamma float64 // Lorentz factor of the particle
+	Clock float64 // Time measured by a Clock on the particle
+
+	PrevPos    Vector  // Position of the particle at the start of the current step, for interpolating when drawing
+	PrevAngPos float64 // Angular position of the particle at the start of the current step
+}
+
+// Update Updates the particle's parameters using the given integrator
+func (p *Particle) Update(force, frame Vector, c, dt float64, integrator Integrator) {
+	relativeVelocity := p.FrameVelocity(frame, c)
+	p.Gamma = Gamma(relativeVelocity.Mag(), c) // Calculate the lorentz factor of from the velocity
+
+	integrator.Integrate(p, force, frame, c, dt)
+}
+
+// ProperVelocity returns the proper velocity of the particle, the rate of change of its position with its own time
+func (p *Particle) ProperVelocity(c float64) Vector {
+	return p.Rap.SetMag(c * math.Sinh(p.Rap.Mag()/c))
+}
+
+// SetProperVelocity sets the velocity and rapidity of the particle from its proper velocity
+func (p *Particle) SetProperVelocity(u Vector, c float64) {
+	p.Vel = u.Scl(1 / properGamma(u, c))
+	p.Rap = u.SetMag(c * math.Asinh(u.Mag()/c))
+}
+
+// ChangeC re-derives the velocity of the particle from its rapidity once the speed of light has changed to c, so that
+// it stays slower than light
+func (p *Particle) ChangeC(c float64) {
+	p.SetProperVelocity(p.ProperVelocity(c), c)
+}
+
+// SavePrevious stores the current state of the particle as the state at the start of the step
+func (p *Particle) SavePrevious() {
+	p.PrevPos = p.Pos
+	p.PrevAngPos = p.AngPos
+}
+
+// Interpolate returns the position and angular position of the particle a fraction alpha of the way from the start
+// of the step to its current state
+func (p *Particle) Interpolate(alpha float64) (pos Vector, angPos float64) {
+	pos = p.PrevPos.Add(p.Pos.Sub(p.PrevPos).Scl(alpha))
+	angPos = p.PrevAngPos + (p.AngPos-p.PrevAngPos)*alpha
+
+	return pos, angPos
+}
+
+// CheckCollision returns true if the particle collides with another particle whilst accounting for length contraction.
+// The distance between the particles is the shortest distance in the topology, so particles collide across its seams.
+func (p *Particle) CheckCollision(q *Particle, frame Vector, c float64, topology Topology) bool {
+	// The displacement from q to p
+	d := topology.Offset(q.Pos, p.Pos)
+
+	// Calculate the collision axis
+	axis := d.Unit()
+
+	// Calculate the radii along the collision axis of the two particles, contracted along their velocities in the frame
+	pRadius := NewBoost(p.FrameVelocity(frame, c), c).Contract(axis.Scl(p.ScaledRadius())).Mag()
+
+	qRadius := NewBoost(q.FrameVelocity(frame, c), c).Contract(axis.Scl(q.ScaledRadius())).Mag()
+
+	if math.IsNaN(pRadius) {
+		pRadius = p.ScaledRadius()
+	}
+
+	if math.IsNaN(qRadius) {
+		qRadius = q.ScaledRadius()
+	}
+
+	// Calculate the distance between the centers of the two particles
+	distance := d.Mag()
+
+	// check whether the distance is greater than the sum of the radii
+	return distance < pRadius+qRadius
+}
+
+// FrameVelocity returns the velocity of the particle as seen by an observer moving with velocity frame
+func (p *Particle) FrameVelocity(frame Vector, c float64) Vector {
+	return NewBoost(frame, c).Velocity(p.Vel)
+}
+
+// FourMomentum returns the four-momentum of the particle
+func (p *Particle) FourMomentum(c float64) FourMomentum {
+	return NewFourMomentum(p.Mass, p.Vel, c)
+}
+
+// SetFourMomentum sets the velocity and rapidity of the particle from its four-momentum
+func (p *Particle) SetFourMomentum(momentum FourMomentum, c float64) {
+	p.Vel = momentum.Velocity(c)
+	p.Rap = p.Vel.SetMag(c * math.Atanh(p.Vel.Mag()/c))
+}
+
+// String returns the string representation of the particle as JSON
+func (p *Particle) String() string {
+	return fmt.Sprintf(
+		"{\n  \"pos\": %v,\n  \"vel\": %v,\n  \"acc\": %v,\n  \"rap\": %v,\n}",
+		p.Pos,
+		p.Vel,
+		p.Acc,
+		p.Rap,
+	)
+}
+
+// ScaledRadius returns the scaled radius of the particle
+func (p *Particle) ScaledRadius() float64 {
+	return p.Radius * physScale
+}
+
+// UpdatePositions updates the positions of particles using the given integrator
+func UpdatePositions(particles []*Particle, frame Vector, c, dt float64, integrator Integrator) {
+	for _, particle := range particles {
+		particle.Update(Vector{}, frame, c, dt, integrator)
+	}
+}
+
+// SolveCollisions is used to handle collisions between particles, using the broad phase to find candidate pairs and
+// the collision model to respond to them. Particles collide across the seams of the topology. Returns the indices of
+// the particles that were merged into other particles.
+func SolveCollisions(particles []*Particle, frame Vector, c float64, bp BroadPhase, model CollisionModel, topology Topology) []int {
+	merged := make([]bool, len(particles))
+	out := make([]int, 0)
+
+	pairs := joinPairs(bp.Pairs(particles), seamPairs(particles, particles, bp, topology), true)
+
+	for _, pair := range pairs {
+		i, j := pair[0], pair[1]
+
+		// Skip particles that no longer exist
+		if merged[i] || merged[j] {
+			continue
+		}
+
+		if particles[i].CheckCollision(particles[j], frame, c, topology) {
+			// Move the second particle to its copy nearest the first while responding to the collision, so that
+			// particles on either side of a seam are pushed apart and merged where they touch
+			image := particles[i].Pos.Sub(topology.Offset(particles[j].Pos, particles[i].Pos)).Sub(particles[j].Pos)
+			particles[j].Pos = particles[j].Pos.Add(image)
+
+			// Get the distance between the two particles
+			distance := particles[i].Pos.Sub(particles[j].Pos).Mag()
+			// Get the collision axis of the two particles
+			axis := particles[i].Pos.Sub(particles[j].Pos).Unit()
+			// Calculate the step size to move the two particles apart
+			stepSize := particles[i].ScaledRadius() + particles[j].ScaledRadius() - distance
+
+			// Move the two particles apart by half the step size
+			particles[i].Pos = particles[i].Pos.Add(axis.Scl(0.5 * stepSize))
+			particles[j].Pos = particles[j].Pos.Sub(axis.Scl(0.5 * stepSize))
+
+			// Respond to the collision along the collision axis
+			if model.Collide(particles[i], particles[j], axis, c) {
+				merged[j] = true
+				out = append(out, j)
+			}
+
+			particles[j].Pos = particles[j].Pos.Sub(image)
+		}
+	}
+
+	return out
+}
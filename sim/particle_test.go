@@ -0,0 +1,172 @@
+package sim
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// physicsIntegrators are the integrators expected to reproduce the analytic results
+var physicsIntegrators = []Integrator{VelocityVerlet{}, RK4{}}
+
+func TestGamma(t *testing.T) {
+	for _, tc := range []struct {
+		v, c, want float64
+	}{
+		{0, 1, 1},
+		{0.6, 1, 1.25},
+		{0.8, 1, 5.0 / 3.0},
+		{3, 5, 1.25},
+		{math.Sqrt(3) / 2 * 100, 100, 2},
+	} {
+		if got := Gamma(tc.v, tc.c); !approxEqual(got, tc.want, 1) {
+			t.Errorf("Gamma(%g, %g) = %g, want %g", tc.v, tc.c, got, tc.want)
+		}
+	}
+
+	// Nothing reaches the speed of light
+	if g := Gamma(1, 1); !math.IsInf(g, 1) {
+		t.Errorf("Gamma(c, c) = %g, want +Inf", g)
+	}
+}
+
+func TestParticleTimeDilation(t *testing.T) {
+	c := 10.0
+
+	for _, integrator := range physicsIntegrators {
+		p := &Particle{Mass: 1}
+		p.SetFourMomentum(NewFourMomentum(p.Mass, Vector{8, 0}, c), c)
+
+		for i := 0; i < 600; i++ {
+			p.Update(Vector{}, Vector{}, c, 1.0/60, integrator)
+		}
+
+		// A clock moving at 0.8c runs at 0.6 of the rate of a stationary clock
+		if !approxEqual(p.Clock, 6, 1e3) {
+			t.Errorf("%T: clock read %g after 10s at 0.8c, want 6", integrator, p.Clock)
+		}
+
+		if !approxEqual(p.Pos.X, 80, 1e3) {
+			t.Errorf("%T: particle moved to %v after 10s at 0.8c, want 80", integrator, p.Pos)
+		}
+
+		// The lorentz factor is measured relative to the frame
+		p.Update(Vector{}, p.Vel, c, 1.0/60, integrator)
+		if !approxEqual(p.Gamma, 1, 1) {
+			t.Errorf("%T: got lorentz factor %g in the rest frame of the particle, want 1", integrator, p.Gamma)
+		}
+	}
+}
+
+func TestParticleTwinParadox(t *testing.T) {
+	const (
+		c     = 10.0
+		a     = 5.0
+		phase = 2.0 // The coordinate time of each quarter of the journey
+		dt    = 1.0 / 120
+	)
+
+	for _, integrator := range physicsIntegrators {
+		home := &Particle{Mass: 1}
+		traveller := &Particle{Mass: 1}
+		furthest := 0.0
+
+		// Accelerate away, turn around and decelerate back home, spending the same coordinate time in each quarter
+		for _, direction := range []float64{1, -1, -1, 1} {
+			for i := 0; i < int(math.Round(phase/dt)); i++ {
+				home.Update(Vector{}, Vector{}, c, dt, integrator)
+				traveller.Update(Vector{X: direction * a * traveller.Mass}, Vector{}, c, dt, integrator)
+				furthest = math.Max(furthest, traveller.Pos.X)
+			}
+		}
+
+		// Each quarter is a stretch of hyperbolic motion
+		pos, _, tau := hyperbolic(a, c, phase)
+
+		if !approxEqual(home.Clock, 4*phase, 1e3) {
+			t.Errorf("%T: twin at home aged %g, want %g", integrator, home.Clock, 4*phase)
+		}
+
+		if math.Abs(traveller.Clock-4*tau) > 1e-3 {
+			t.Errorf("%T: travelling twin aged %g, want %g", integrator, traveller.Clock, 4*tau)
+		}
+
+		if traveller.Clock >= home.Clock {
+			t.Errorf("%T: travelling twin aged %g, more than the twin at home %g", integrator, traveller.Clock, home.Clock)
+		}
+
+		// The traveller turns around twice as far away as the end of the first quarter and comes back to rest at home
+		if math.Abs(furthest-2*pos.X) > 1e-3*furthest {
+			t.Errorf("%T: travelling twin turned around at %g, want %g", integrator, furthest, 2*pos.X)
+		}
+
+		if traveller.Pos.Mag() > 1e-3*furthest || traveller.Vel.Mag() > 1e-3*c {
+			t.Errorf("%T: travelling twin came back to %v with velocity %v", integrator, traveller.Pos, traveller.Vel)
+		}
+	}
+}
+
+func TestSolveCollisionsHeadOn(t *testing.T) {
+	c := 1.0
+	// At 0.9c the particles are contracted to less than half their size along the axis, so they must be close to touch
+	p := &Particle{Mass: 1, Radius: 1, Pos: Vector{-0.1, 0}}
+	q := &Particle{Mass: 1, Radius: 1, Pos: Vector{0.1, 0}}
+	p.SetFourMomentum(NewFourMomentum(1, Vector{0.9, 0}, c), c)
+	q.SetFourMomentum(NewFourMomentum(1, Vector{-0.9, 0}, c), c)
+
+	merged := SolveCollisions([]*Particle{p, q}, Vector{}, c, BruteForce{}, Elastic{}, Unbounded{})
+	if len(merged) != 0 {
+		t.Fatalf("elastic collision merged particles %v", merged)
+	}
+
+	// Equal masses colliding head on exchange their velocities
+	if !approxEqual(p.Vel.X, -0.9, 1) || !approxEqual(q.Vel.X, 0.9, 1) ||
+		!approxEqual(p.Vel.Y, 0, 1) || !approxEqual(q.Vel.Y, 0, 1) {
+		t.Fatalf("got velocities %v and %v, want them exchanged", p.Vel, q.Vel)
+	}
+
+	// The particles are pushed apart until they just touch
+	if d := p.Pos.Dist(q.Pos); !approxEqual(d, p.ScaledRadius()+q.ScaledRadius(), 1) {
+		t.Fatalf("particles are %g apart after the collision, want %g", d, p.ScaledRadius()+q.ScaledRadius())
+	}
+}
+
+func TestSolveCollisionsConservesMomentum(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	c := 10.0
+
+	for _, model := range []CollisionModel{Elastic{}, Restitution{0.5}, Merge{}} {
+		// A crowd of particles packed closely enough that many of them collide
+		particles := make([]*Particle, 64)
+		for i := range particles {
+			particles[i] = randParticle(rng, c)
+			particles[i].Pos = randUnit(rng).Scl(rng.Float64() * 3)
+		}
+
+		total := func(skip []int) FourMomentum {
+			removed := make(map[int]bool)
+			for _, i := range skip {
+				removed[i] = true
+			}
+
+			var sum FourMomentum
+			for i, p := range particles {
+				if !removed[i] {
+					sum = sum.Add(p.FourMomentum(c))
+				}
+			}
+
+			return sum
+		}
+
+		before := total(nil)
+		after := total(SolveCollisions(particles, Vector{}, c, BruteForce{}, model, Unbounded{}))
+
+		scale := before.T
+		if !approxEqual(after.T/scale, before.T/scale, 1e3) ||
+			!approxEqual(after.X/scale, before.X/scale, 1e3) ||
+			!approxEqual(after.Y/scale, before.Y/scale, 1e3) {
+			t.Errorf("%+v: four-momentum changed from %v to %v", model, before, after)
+		}
+	}
+}
@@ -0,0 +1,424 @@
+package sim
+
+import (
+	"fmt"
+	"github.com/charmbracelet/log"
+	"math"
+	"time"
+)
+
+// Pool is a struct for storing particles
+type Pool struct {
+	// Arrays storing the particles information
+	particles []*Particle // particles
+	active    []bool      // Whether a particle is active or not
+	lifetimes []float64   // The simulation time at which each particle was activated
+	sprites   []int       // Sprite sheet indices of particles
+	histories []*History  // Recent states of particles, nil unless history is tracked
+
+	maxLifetime      time.Duration // How long for particles to live
+	enforceLifetime  bool          // Whether to enforce the maxLifetime of particles
+	fadeOverLifetime bool          // Whether to fade out particles over their lifetime
+
+	disableCollision bool           // Whether to collide with other particles in the same pool
+	broadPhase       BroadPhase     // The broad phase used to find candidate collisions
+	collisionModel   CollisionModel // How colliding particles respond to a collision
+	integrator       Integrator     // How the motion of the particles is integrated
+	topology         Topology       // The shape of space, particles collide across its seams
+
+	historyLength int // How many samples of history to keep for each particle, 0 if history is not tracked
+
+	clock float64 // The simulation time of the pool, advanced on every update
+}
+
+// NewPool returns a new pool of n particles.
+func NewPool(n int) *Pool {
+	log.Debug("creating new pool", "n", n)
+	return &Pool{
+		particles: make([]*Particle, n),
+		active:    make([]bool, n),
+		lifetimes: make([]float64, n),
+		sprites:   make([]int, n),
+		histories: make([]*History, n),
+
+		broadPhase:     BruteForce{},
+		collisionModel: Elastic{},
+		integrator:     VelocityVerlet{},
+		topology:       Unbounded{},
+	}
+}
+
+// DisableCollision disables collision with other particles in the same pool.
+func (p *Pool) DisableCollision() *Pool {
+	log.Debug("pool collisions disabled")
+	p.disableCollision = true
+	return p
+}
+
+// SetBroadPhase sets the broad phase used to find candidate collisions.
+func (p *Pool) SetBroadPhase(bp BroadPhase) *Pool {
+	log.Debug("pool broad phase set", "broadPhase", fmt.Sprintf("%T", bp))
+	p.broadPhase = bp
+	return p
+}
+
+// SetCollisionModel sets how particles in the same pool respond to colliding with each other.
+func (p *Pool) SetCollisionModel(m CollisionModel) *Pool {
+	log.Debug("pool collision model set", "collisionModel", fmt.Sprintf("%+v", m))
+	p.collisionModel = m
+	return p
+}
+
+// SetIntegrator sets how the motion of the particles is integrated.
+func (p *Pool) SetIntegrator(i Integrator) *Pool {
+	log.Debug("pool integrator set", "integrator", fmt.Sprintf("%T", i))
+	p.integrator = i
+	return p
+}
+
+// SetTopology sets the shape of space, so that particles collide across its seams.
+func (p *Pool) SetTopology(t Topology) *Pool {
+	log.Debug("pool topology set", "topology", fmt.Sprintf("%+v", t))
+	p.topology = t
+	return p
+}
+
+// EnforceLifetime enforces the maxLifetime of particles.
+func (p *Pool) EnforceLifetime(lifetime time.Duration) *Pool {
+	log.Debug("pool lifetime enforcement enabled", "lifetime", lifetime.String())
+	p.enforceLifetime = true
+	p.maxLifetime = lifetime
+	return p
+}
+
+// FadeOverLifetime enables fading out particles over their lifetime.
+func (p *Pool) FadeOverLifetime() *Pool {
+	log.Debug("pool lifetime fading enabled")
+	p.fadeOverLifetime = true
+	return p
+}
+
+// TrackHistory enables keeping a history of the states of each particle, going back the given duration when a sample
+// is recorded every interval seconds.
+func (p *Pool) TrackHistory(duration time.Duration, interval float64) *Pool {
+	log.Debug("pool history tracking enabled", "duration", duration.String(), "interval", interval)
+	p.historyLength = int(duration.Seconds()/interval) + 1
+	return p
+}
+
+// History returns the recorded history of the particle with the given index, or nil if it has none.
+func (p *Pool) History(i int) *History {
+	return p.histories[i]
+}
+
+// age returns how long the particle with the given index has been active for in simulation time.
+func (p *Pool) age(i int) float64 {
+	return p.clock - p.lifetimes[i]
+}
+
+// Particle returns the particle with the given index, or nil if it isn't active.
+func (p *Pool) Particle(i int) *Particle {
+	return p.particles[i]
+}
+
+// Sprite returns the sprite sheet index of the particle with the given index.
+func (p *Pool) Sprite(i int) int {
+	return p.sprites[i]
+}
+
+// Opacity returns how opaque the particle with the given index is drawn, from 0 to 1. Particles fade out over their
+// lifetime if the pool fades them, and are fully opaque otherwise.
+func (p *Pool) Opacity(i int) float64 {
+	if !p.fadeOverLifetime {
+		return 1
+	}
+
+	return 1 - p.age(i)/p.maxLifetime.Seconds()
+}
+
+// ChangeC re-derives the velocities of all active particles in the pool from their rapidities once the speed of light
+// has changed to c
+func (p *Pool) ChangeC(c float64) {
+	for i := 0; i < len(p.particles); i++ {
+		if p.active[i] {
+			p.particles[i].ChangeC(c)
+		}
+	}
+}
+
+// Update updates all particles in the pool.
+func (p *Pool) Update(frame Vector, c float64, dt float64) {
+	p.UpdateWith(frame, c, dt)
+}
+
+// UpdateWith updates all particles in the pool, plus solves collisions with the given particles.
+// The given particles always collide elastically, as they cannot be merged into the pool.
+func (p *Pool) UpdateWith(frame Vector, c float64, dt float64, particles ...*Particle) {
+	p.clock += dt
+
+	activeIndices := make([]int, 0, len(p.particles))
+	activeParticles := make([]*Particle, 0, len(p.particles)+len(particles))
+
+	// Select all active particles
+	for i := 0; i < len(p.particles); i++ {
+		if p.enforceLifetime && p.active[i] {
+			if p.age(i) > p.maxLifetime.Seconds() {
+				_ = p.Deactivate(i)
+				continue
+			}
+		}
+
+		if p.active[i] {
+			activeIndices = append(activeIndices, i)
+			activeParticles = append(activeParticles, p.particles[i])
+		}
+	}
+
+	// Update positions
+	UpdatePositions(activeParticles, frame, c, dt, p.integrator)
+
+	// Add the given particles to the active particles
+	activeParticles = append(activeParticles, particles...)
+
+	// If collisions are enabled, solve collisions
+	if !p.disableCollision {
+		model := p.collisionModel
+		if len(particles) > 0 {
+			model = externalModel{model, particles}
+		}
+
+		// Remove the particles that were merged into others
+		for _, i := range SolveCollisions(activeParticles, frame, c, p.broadPhase, model, p.topology) {
+			_ = p.Deactivate(activeIndices[i])
+		}
+	}
+}
+
+// RecordHistory records the current state of every active particle, if history is tracked.
+func (p *Pool) RecordHistory() {
+	if p.historyLength == 0 {
+		return
+	}
+
+	for i := 0; i < len(p.particles); i++ {
+		if p.active[i] {
+			if p.histories[i] == nil {
+				p.histories[i] = NewHistory(p.historyLength)
+			}
+
+			p.histories[i].Record(p.particles[i].Sample(p.clock))
+		}
+	}
+}
+
+// SavePrevious stores the current state of every active particle as the state at the start of the step.
+func (p *Pool) SavePrevious() {
+	for i := 0; i < len(p.particles); i++ {
+		if p.active[i] {
+			p.particles[i].SavePrevious()
+		}
+	}
+}
+
+// Activate activates a particle with the given parameters. Returns the activated particle
+func (p *Pool) Activate(pos, rap Vector, angPos, angVel, mass, radius float64, sprite int) *Particle {
+	log.Debug("activating particle", "pos", pos.String(), "rap", rap.String(), "angPos", angPos, "angVel", angVel, "mass", mass, "radius", radius, "sprite", sprite)
+
+	// Search for inactive particles to activate
+	for i := 0; i < len(p.particles); i++ {
+		// If the particle is inactive, activate it
+		if !p.active[i] {
+			p.active[i] = true
+			p.lifetimes[i] = p.clock
+			p.sprites[i] = sprite
+			p.histories[i] = nil
+			p.particles[i] = &Particle{
+				Pos:    pos,
+				Rap:    rap,
+				Vel:    Vector{},
+				Acc:    Vector{},
+				AngPos: angPos,
+				AngVel: angVel,
+				Mass:   mass,
+				Radius: radius,
+				Gamma:  1,
+				Clock:  0,
+
+				PrevPos:    pos,
+				PrevAngPos: angPos,
+			}
+			return p.particles[i]
+		}
+	}
+
+	log.Debug("failed to find inactive particle")
+
+	// If there are no more inactive particles, create a new one
+	return p.appendNew(pos, rap, angPos, angVel, mass, radius, sprite)
+}
+
+// appendNew adds a new particle to the pool by resizing the pool, to be used when the pool is full. Returns the new
+// particle.
+func (p *Pool) appendNew(pos, rap Vector, angPos, angVel, mass, radius float64, sprite int) *Particle {
+	log.Debug("appending new particle", "pos", pos.String(), "rap", rap.String(), "angPos", angPos, "angVel", angVel, "mass", mass, "radius", radius, "sprite", sprite)
+	p.active = append(p.active, true)
+	p.lifetimes = append(p.lifetimes, p.clock)
+	p.sprites = append(p.sprites, sprite)
+	p.histories = append(p.histories, nil)
+	p.particles = append(p.particles, &Particle{
+		Pos:    pos,
+		Rap:    rap,
+		Vel:    Vector{},
+		Acc:    Vector{},
+		AngPos: angPos,
+		AngVel: angVel,
+		Mass:   mass,
+		Radius: radius,
+		Gamma:  1,
+		Clock:  0,
+
+		PrevPos:    pos,
+		PrevAngPos: angPos,
+	})
+
+	return p.particles[len(p.particles)-1]
+}
+
+// Deactivate deactivates a particle with the given index. Returns true on success
+func (p *Pool) Deactivate(i int) bool {
+	log.Debug("deactivating particle", "i", i)
+
+	if p.active[i] {
+		p.active[i] = false
+		p.particles[i] = nil
+		p.histories[i] = nil
+		return true
+	}
+
+	return false
+}
+
+// Translate moves the particle with the given index by d, along with its previous position and history, so that it
+// carries on as if it had always been there
+func (p *Pool) Translate(i int, d Vector) {
+	particle := p.particles[i]
+	particle.Pos = particle.Pos.Add(d)
+	particle.PrevPos = particle.PrevPos.Add(d)
+
+	if p.histories[i] != nil {
+		p.histories[i].Shift(d)
+	}
+}
+
+// Teleport moves the particle with the given index to pos and forgets its history, so that it isn't drawn flying
+// across the world
+func (p *Pool) Teleport(i int, pos Vector) {
+	p.particles[i].Pos = pos
+	p.particles[i].SavePrevious()
+	p.histories[i] = nil
+}
+
+// PoolCollisions checks if any particles in a pool collide with any particles in another pool
+func (p *Pool) PoolCollisions(other *Pool, frame Vector, c float64) [][2]int {
+	out := make([][2]int, 0)
+
+	indices, particles := p.ActiveIndices()
+	otherIndices, otherParticles := other.ActiveIndices()
+
+	pairs := joinPairs(
+		p.broadPhase.CrossPairs(particles, otherParticles),
+		seamPairs(particles, otherParticles, p.broadPhase, p.topology),
+		false,
+	)
+
+	for _, pair := range pairs {
+		if particles[pair[0]].CheckCollision(otherParticles[pair[1]], frame, c, p.topology) {
+			out = append(out, [2]int{indices[pair[0]], otherIndices[pair[1]]})
+		}
+	}
+
+	return out
+}
+
+// Collisions checks if a particle in a pool collides with a particle
+func (p *Pool) Collisions(particle *Particle, frame Vector, c float64) []int {
+	out := make([]int, 0)
+
+	indices, particles := p.ActiveIndices()
+
+	pairs := joinPairs(
+		p.broadPhase.CrossPairs(particles, []*Particle{particle}),
+		seamPairs(particles, []*Particle{particle}, p.broadPhase, p.topology),
+		false,
+	)
+
+	for _, pair := range pairs {
+		if particles[pair[0]].CheckCollision(particle, frame, c, p.topology) {
+			out = append(out, indices[pair[0]])
+		}
+	}
+
+	return out
+}
+
+// ActiveIndices returns the indices of all active particles in the pool, along with the particles themselves.
+func (p *Pool) ActiveIndices() ([]int, []*Particle) {
+	indices := make([]int, 0, len(p.particles))
+	particles := make([]*Particle, 0, len(p.particles))
+
+	for i := 0; i < len(p.particles); i++ {
+		if p.active[i] {
+			indices = append(indices, i)
+			particles = append(particles, p.particles[i])
+		}
+	}
+
+	return indices, particles
+}
+
+// Active returns all active particles in the pool.
+func (p *Pool) Active() []*Particle {
+	out := make([]*Particle, 0, len(p.particles))
+
+	for i := 0; i < len(p.particles); i++ {
+		if p.active[i] {
+			out = append(out, p.particles[i])
+		}
+	}
+
+	return out
+}
+
+// Closest returns the position of the closest particle in the pool to the given position. Across the seams of the
+// topology this is the position of the copy of the particle nearest to pos.
+func (p *Pool) Closest(pos Vector) Vector {
+	cPos := Vector{}
+	minSqrDist := math.MaxFloat64
+
+	for i := 0; i < len(p.particles); i++ {
+		if p.active[i] {
+			offset := p.topology.Offset(pos, p.particles[i].Pos)
+			if sqrDist := offset.SqrMag(); sqrDist < minSqrDist {
+				minSqrDist = sqrDist
+				cPos = pos.Add(offset)
+			}
+		}
+	}
+
+	return cPos
+}
+
+// Reset clears the pool
+func (p *Pool) Reset() {
+	log.Debug("resetting pool")
+
+	for i := 0; i < len(p.particles); i++ {
+		if p.active[i] {
+			p.particles[i] = nil
+			p.active[i] = false
+			p.lifetimes[i] = 0
+			p.sprites[i] = 0
+			p.histories[i] = nil
+		}
+	}
+}
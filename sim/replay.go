@@ -0,0 +1,300 @@
+package sim
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/charmbracelet/log"
+	"io"
+	"math"
+	"os"
+)
+
+// Defining the replay file format.
+//
+// A replay file starts with the magic string, followed by the version, the seed of the world and the number of
+// ticks, all little endian. Then come the length of the config of the world and the config itself as JSON, and the
+// length of the scenario the world was set up from and the scenario itself as JSON, with a length of zero if there was
+// no scenario. Each tick is then stored as a byte of input flags, the thrust as an unsigned byte and the rotation as a
+// signed byte.
+const (
+	replayMagic   = "RAREPLAY"
+	replayVersion = uint16(1)
+)
+
+// Defining the input flags stored for each tick of a replay.
+const (
+	inputFire byte = 1 << iota
+	inputEscape
+)
+
+// inputSize is the number of bytes used to store the input of a tick
+const inputSize = 3
+
+// Limiting what a corrupt replay file can make the decoder allocate.
+const (
+	maxReplayJSON     = 16 << 20 // The longest config or scenario a replay can store, in bytes
+	maxReplayPrealloc = 1 << 16  // The most ticks of input allocated up front, the rest are only allocated once read
+)
+
+// Replay is a recording of a run, consisting of the seed and config of the world and the input for every tick
+type Replay struct {
+	Seed     int64     // The seed the world was created with
+	Config   Config    // The config the world was created with, with the settings of the scenario applied
+	Scenario *Scenario // The scenario the world was set up from, nil for a normal run
+	Inputs   []Input   // The input for each tick of the run
+}
+
+// NewReplay returns a new empty replay for a world created with the given seed and config, and set up from the
+// scenario if it isn't nil
+func NewReplay(seed int64, cfg Config, sc *Scenario) *Replay {
+	log.Debug("creating new replay", "seed", seed)
+	return &Replay{
+		Seed:     seed,
+		Config:   cfg,
+		Scenario: sc,
+		Inputs:   make([]Input, 0),
+	}
+}
+
+// Record appends the input of the next tick to the replay
+func (r *Replay) Record(in Input) {
+	r.Inputs = append(r.Inputs, in)
+}
+
+// Input returns the input for the given tick, ok is false once the replay has run out of input
+func (r *Replay) Input(tick int) (in Input, ok bool) {
+	if tick < 0 || tick >= len(r.Inputs) {
+		return Input{}, false
+	}
+
+	return r.Inputs[tick], true
+}
+
+// Playback returns the input for the given tick while the replay is played back and the player gives the live input.
+// The recorded input is used, except that the player can still quit the run.
+func (r *Replay) Playback(tick int, live Input) Input {
+	in, _ := r.Input(tick)
+	in.Escape = in.Escape || live.Escape
+
+	return in
+}
+
+// Save writes the replay to the file at path
+func (r *Replay) Save(path string) error {
+	log.Debug("saving replay", "path", path, "ticks", len(r.Inputs))
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := r.Encode(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// Encode writes the replay to w
+func (r *Replay) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	if _, err := bw.WriteString(replayMagic); err != nil {
+		return err
+	}
+
+	cfg, err := json.Marshal(r.Config)
+	if err != nil {
+		return err
+	}
+
+	// Write the header
+	for _, v := range []any{replayVersion, r.Seed, uint32(len(r.Inputs)), uint32(len(cfg))} {
+		if err := binary.Write(bw, binary.LittleEndian, v); err != nil {
+			return err
+		}
+	}
+
+	if _, err := bw.Write(cfg); err != nil {
+		return err
+	}
+
+	var scenario []byte
+	if r.Scenario != nil {
+		if scenario, err = json.Marshal(r.Scenario); err != nil {
+			return err
+		}
+	}
+
+	if err := binary.Write(bw, binary.LittleEndian, uint32(len(scenario))); err != nil {
+		return err
+	}
+
+	if _, err := bw.Write(scenario); err != nil {
+		return err
+	}
+
+	// Write the input for each tick
+	for _, in := range r.Inputs {
+		data := in.encode()
+		if _, err := bw.Write(data[:]); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// LoadReplay reads the replay from the file at path
+func LoadReplay(path string) (*Replay, error) {
+	log.Debug("loading replay", "path", path)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return DecodeReplay(f)
+}
+
+// DecodeReplay reads a replay from r
+func DecodeReplay(r io.Reader) (*Replay, error) {
+	br := bufio.NewReader(r)
+
+	// Check the magic string
+	magic := make([]byte, len(replayMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return nil, fmt.Errorf("failed to read replay header: %w", err)
+	}
+
+	if string(magic) != replayMagic {
+		return nil, errors.New("not a replay file")
+	}
+
+	// Read the header
+	var (
+		version uint16
+		seed    int64
+		ticks   uint32
+	)
+
+	for _, v := range []any{&version, &seed, &ticks} {
+		if err := binary.Read(br, binary.LittleEndian, v); err != nil {
+			return nil, fmt.Errorf("failed to read replay header: %w", err)
+		}
+	}
+
+	if version != replayVersion {
+		return nil, fmt.Errorf("unsupported replay version %d", version)
+	}
+
+	data, err := readReplayBlock(br, "config")
+	if err != nil {
+		return nil, err
+	}
+
+	// Settings missing from the config keep their defaults
+	cfg := DefaultConfig()
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse replay config: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid replay config: %w", err)
+	}
+
+	if data, err = readReplayBlock(br, "scenario"); err != nil {
+		return nil, err
+	}
+
+	var scenario *Scenario
+	if len(data) > 0 {
+		if scenario, err = DecodeScenario(bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("invalid replay scenario: %w", err)
+		}
+	}
+
+	out := &Replay{
+		Seed:     seed,
+		Config:   cfg,
+		Scenario: scenario,
+		Inputs:   make([]Input, 0, min(ticks, maxReplayPrealloc)),
+	}
+
+	// The number of ticks in the header isn't trusted, the inputs are only allocated as they are read, so a corrupt
+	// count runs out of input instead of memory
+	var in [inputSize]byte
+	for i := uint32(0); i < ticks; i++ {
+		if _, err := io.ReadFull(br, in[:]); err != nil {
+			return nil, fmt.Errorf("failed to read replay inputs: %w", err)
+		}
+
+		out.Inputs = append(out.Inputs, decodeInput(in))
+	}
+
+	return out, nil
+}
+
+// readReplayBlock reads a block of the replay stored as its length followed by its data. Only as much as is actually
+// in the replay is allocated, however long the block claims to be.
+func readReplayBlock(br *bufio.Reader, name string) ([]byte, error) {
+	var n uint32
+	if err := binary.Read(br, binary.LittleEndian, &n); err != nil {
+		return nil, fmt.Errorf("failed to read replay header: %w", err)
+	}
+
+	if n > maxReplayJSON {
+		return nil, fmt.Errorf("replay %s is too long, got %d bytes", name, n)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(br, int64(n)))
+	if err == nil && len(data) < int(n) {
+		err = io.ErrUnexpectedEOF
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replay %s: %w", name, err)
+	}
+
+	return data, nil
+}
+
+// encode returns the input packed into the bytes stored for each tick. The thrust and rotation are quantised, so
+// only inputs returned by Quantised are stored exactly.
+func (in Input) encode() [inputSize]byte {
+	var flags byte
+	if in.Fire {
+		flags |= inputFire
+	}
+
+	if in.Escape {
+		flags |= inputEscape
+	}
+
+	thrust := math.Round(math.Max(0, math.Min(1, in.Thrust)) * math.MaxUint8)
+	rotate := math.Round(math.Max(-1, math.Min(1, in.Rotate)) * math.MaxInt8)
+
+	return [inputSize]byte{flags, byte(thrust), byte(int8(rotate))}
+}
+
+// decodeInput unpacks the bytes stored for each tick
+func decodeInput(data [inputSize]byte) Input {
+	return Input{
+		Thrust: float64(data[1]) / math.MaxUint8,
+		Rotate: float64(int8(data[2])) / math.MaxInt8,
+		Fire:   data[0]&inputFire != 0,
+		Escape: data[0]&inputEscape != 0,
+	}
+}
+
+// Quantised returns the input as it is stored in a replay. The world must be stepped with quantised input for a
+// replay of the run to reproduce it.
+func (in Input) Quantised() Input {
+	return decodeInput(in.encode())
+}
@@ -0,0 +1,164 @@
+package sim
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReplayRoundTrip(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Asteroids = 12
+
+	r := NewReplay(42, cfg, nil)
+	r.Record(Input{Thrust: 1, Rotate: -1})
+	r.Record(Input{Thrust: 0.5, Rotate: 0.25, Fire: true})
+	r.Record(Input{Escape: true})
+
+	var buf bytes.Buffer
+	if err := r.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := DecodeReplay(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Seed != r.Seed || got.Config != r.Config || len(got.Inputs) != len(r.Inputs) {
+		t.Fatalf("got %+v, want %+v", got, r)
+	}
+
+	// Inputs come back quantised, and quantised inputs are stored exactly
+	for i, in := range r.Inputs {
+		if got.Inputs[i] != in.Quantised() || got.Inputs[i].Quantised() != got.Inputs[i] {
+			t.Fatalf("tick %d: got %+v, want %+v", i, got.Inputs[i], in.Quantised())
+		}
+	}
+}
+
+func TestReplayPlayback(t *testing.T) {
+	// The replay has to carry the config, as a world with the default integrator would move differently
+	cfg := DefaultConfig()
+	cfg.Integrator = "rk4"
+
+	// Record a run the way the game does, stepping the world with the input as it is stored
+	w := NewWorld(42, cfg)
+	r := NewReplay(42, cfg, nil)
+	for _, in := range randomInputs(2, 20*cfg.PhysicsRate) {
+		in = in.Quantised()
+		r.Record(in)
+		w.Step(in)
+	}
+
+	var buf bytes.Buffer
+	if err := r.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := DecodeReplay(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Playing the decoded replay back into a fresh world ends in the same state
+	p := NewWorld(got.Seed, got.Config)
+	for tick := 0; ; tick++ {
+		in, ok := got.Input(tick)
+		if !ok {
+			break
+		}
+
+		p.Step(in)
+	}
+
+	if p.Tick != w.Tick || p.Score != w.Score || p.Health != w.Health || p.Ammo != w.Ammo || p.Ended != w.Ended {
+		t.Fatalf("played back to tick %d with score %d, recorded tick %d with score %d", p.Tick, p.Score, w.Tick, w.Score)
+	}
+
+	if !reflect.DeepEqual(p.Ship, w.Ship) || !reflect.DeepEqual(p.Asteroids, w.Asteroids) || !reflect.DeepEqual(p.Bullets, w.Bullets) {
+		t.Fatal("played back world differs from the recorded one")
+	}
+}
+
+func TestReplayPlaybackQuit(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Asteroids = 0
+
+	// A run that never ends by itself
+	r := NewReplay(42, cfg, nil)
+	for i := 0; i < 10*cfg.PhysicsRate; i++ {
+		r.Record(Input{Rotate: 0.5}.Quantised())
+	}
+
+	// The live input is ignored while playing back, until the player quits from the pause menu
+	w := NewWorld(r.Seed, r.Config)
+	for tick := 0; tick < len(r.Inputs) && !w.Ended; tick++ {
+		live := Input{Thrust: 1, Fire: true, Escape: tick == 100}
+
+		in := r.Playback(tick, live)
+		if want := r.Inputs[tick]; in.Thrust != want.Thrust || in.Rotate != want.Rotate || in.Fire != want.Fire {
+			t.Fatalf("tick %d: got input %+v, want the recorded %+v", tick, in, want)
+		}
+
+		w.Step(in)
+	}
+
+	if !w.Ended || w.EndTick != 101 {
+		t.Fatalf("ended = %v at tick %d, want the run to end when the player quit", w.Ended, w.EndTick)
+	}
+}
+
+func TestDecodeReplayCorrupt(t *testing.T) {
+	var valid bytes.Buffer
+	if err := NewReplay(7, DefaultConfig(), nil).Encode(&valid); err != nil {
+		t.Fatal(err)
+	}
+
+	// header returns a replay header with the given number of ticks and config length, followed by the given data
+	header := func(ticks, n uint32, data []byte) []byte {
+		var buf bytes.Buffer
+		buf.WriteString(replayMagic)
+		for _, v := range []any{replayVersion, int64(7), ticks, n} {
+			_ = binary.Write(&buf, binary.LittleEndian, v)
+		}
+		buf.Write(data)
+		return buf.Bytes()
+	}
+
+	// The config and scenario of the valid replay, without its header
+	rest := valid.Bytes()[len(replayMagic)+2+8+4+4:]
+
+	for _, tc := range []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"huge config", header(0, math.MaxUint32, nil), "config is too long"},
+		{"short config", header(0, 1000, []byte("{}")), "failed to read replay config"},
+		{"huge tick count", header(math.MaxUint32, uint32(len(rest)-4), rest), "failed to read replay inputs"},
+	} {
+		_, err := DecodeReplay(bytes.NewReader(tc.data))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: got error %v, want one containing %q", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestDecodeReplayVersion(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewReplay(7, DefaultConfig(), nil).Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// Replays of any other version are rejected rather than played back differently to how they were recorded
+	data := buf.Bytes()
+	binary.LittleEndian.PutUint16(data[len(replayMagic):], replayVersion+1)
+
+	if _, err := DecodeReplay(bytes.NewReader(data)); err == nil || !strings.Contains(err.Error(), "unsupported replay version") {
+		t.Fatalf("got error %v, want an unsupported version", err)
+	}
+}
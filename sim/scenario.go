@@ -0,0 +1,268 @@
+package sim
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/charmbracelet/log"
+	"io"
+	"math"
+	"os"
+	"time"
+)
+
+// scenarioSprites is the number of sprites of each pool that particles can be placed in by a scenario
+var scenarioSprites = map[string]int{
+	"asteroids": 2, // A big asteroid, which breaks into small asteroids when hit, and a small asteroid
+	"bullets":   1,
+	"explosion": 1,
+}
+
+// Scenario describes the initial conditions of a run, how it is won or lost and the frame it is drawn in. Scenarios
+// are stored as JSON files, so that prepared setups can be shared.
+type Scenario struct {
+	Name        string `json:"name"`        // The name of the scenario, shown when it starts
+	Description string `json:"description"` // What the scenario shows, shown below the name
+
+	// Settings overrides settings of the config, such as the speed of light or the drag. It is stored in the same
+	// format as a config file, settings missing from it are left unchanged.
+	Settings json.RawMessage `json:"settings,omitempty"`
+
+	Sandbox   bool               `json:"sandbox"`   // Whether the ship can't be damaged, and the speed of light is only changed by the settings
+	Ship      *ScenarioParticle  `json:"ship"`      // The initial state of the ship, at rest at the origin if left out
+	Particles []ScenarioParticle `json:"particles"` // The particles in the world at the start of the run
+
+	Win    ScenarioCondition `json:"win"`    // The run is won once this is met
+	Lose   ScenarioCondition `json:"lose"`   // The run is lost once this is met, as well as when the ship is destroyed
+	Camera ScenarioCamera    `json:"camera"` // The frame the world is drawn in
+}
+
+// ScenarioParticle is the initial state of a particle in a scenario. Its velocity is given either as a rapidity or
+// as a fraction of the speed of light.
+type ScenarioParticle struct {
+	Pool     string  `json:"pool"`     // The pool the particle is added to: "asteroids", "bullets" or "explosion", asteroids if empty
+	Pos      Vector  `json:"pos"`      // The position of the particle
+	Rapidity Vector  `json:"rapidity"` // The rapidity of the particle, in units of velocity
+	Beta     Vector  `json:"beta"`     // The velocity of the particle as a fraction of the speed of light
+	Angle    float64 `json:"angle"`    // The angular position of the particle
+	Spin     float64 `json:"spin"`     // The angular velocity of the particle
+	Mass     float64 `json:"mass"`     // The mass of the particle, the area of its disc if left out, the ship's mass can't be changed
+	Radius   float64 `json:"radius"`   // The radius of the particle, 1 if left out
+	Sprite   int     `json:"sprite"`   // The sprite of the particle in its pool, for asteroids 0 is big and 1 is small
+}
+
+// ScenarioCondition is a condition that ends a run, which is met as soon as any of its parts is. Parts that are left
+// out are never met, so an empty condition is never met.
+type ScenarioCondition struct {
+	Cleared bool     `json:"cleared,omitempty"` // Every asteroid has been destroyed
+	Time    Duration `json:"time,omitempty"`    // This much time has passed in the rest frame of the asteroid field
+	Reach   *Vector  `json:"reach,omitempty"`   // The ship is within Radius of this position
+	Radius  float64  `json:"radius,omitempty"`  // How close the ship has to be to the position it has to reach
+}
+
+// ScenarioCamera is the frame a scenario is drawn in
+type ScenarioCamera struct {
+	Beta *Vector `json:"beta,omitempty"` // The velocity of the frame as a fraction of the speed of light, the frame of the ship if left out
+}
+
+// LoadScenario reads the scenario from the JSON file at path
+func LoadScenario(path string) (*Scenario, error) {
+	log.Debug("loading scenario", "path", path)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	s, err := DecodeScenario(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load scenario %s: %w", path, err)
+	}
+
+	return s, nil
+}
+
+// DecodeScenario reads a scenario from r and validates it
+func DecodeScenario(r io.Reader) (*Scenario, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	s := new(Scenario)
+	if err := dec.Decode(s); err != nil {
+		return nil, err
+	}
+
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Validate returns an error describing everything invalid about the scenario, or nil if it is valid
+func (s *Scenario) Validate() error {
+	var errs []error
+
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	if _, err := s.Apply(DefaultConfig()); err != nil {
+		errs = append(errs, err)
+	}
+
+	if s.Ship != nil {
+		check(s.Ship.Pool == "", "the ship can't be put in a pool, got %q", s.Ship.Pool)
+		check(s.Ship.Sprite == 0, "the ship has no sprites, got %d", s.Ship.Sprite)
+		check(s.Ship.Mass == 0, "the mass of the ship can't be changed, got %g", s.Ship.Mass)
+		errs = append(errs, s.Ship.validate("ship"))
+	}
+
+	for i, p := range s.Particles {
+		name := fmt.Sprintf("particle %d", i)
+
+		sprites, ok := scenarioSprites[p.pool()]
+		check(ok, "%s: unknown pool %q, must be asteroids, bullets or explosion", name, p.Pool)
+		check(!ok || p.Sprite >= 0 && p.Sprite < sprites, "%s: the %s pool has no sprite %d", name, p.pool(), p.Sprite)
+		errs = append(errs, p.validate(name))
+	}
+
+	errs = append(errs, s.Win.validate("win"), s.Lose.validate("lose"))
+
+	if b := s.Camera.Beta; b != nil {
+		check(b.Mag() < 1, "camera: the frame must be slower than light, got %gc", b.Mag())
+	}
+
+	return errors.Join(errs...)
+}
+
+// Apply returns cfg with the settings of the scenario applied on top of it, and an error if the result is invalid
+func (s *Scenario) Apply(cfg Config) (Config, error) {
+	if len(s.Settings) == 0 {
+		return cfg, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(s.Settings))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse scenario settings: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("invalid scenario settings: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// populate sets up the ship and adds the particles of the scenario to the pools of w
+func (s *Scenario) populate(w *World) {
+	if s.Ship != nil {
+		w.Ship.Pos = s.Ship.Pos
+		w.Ship.AngPos = s.Ship.Angle
+		w.Ship.AngVel = s.Ship.Spin
+		w.Ship.Radius = s.Ship.radius()
+		w.Ship.Rap = s.Ship.rapidity(w.C)
+		w.Ship.SetProperVelocity(w.Ship.ProperVelocity(w.C), w.C) // Derive the velocity from the rapidity
+		w.Ship.SavePrevious()
+	}
+
+	pools := map[string]*Pool{
+		"asteroids": w.Asteroids,
+		"bullets":   w.Bullets,
+		"explosion": w.Explosion,
+	}
+
+	for _, sp := range s.Particles {
+		p := pools[sp.pool()].Activate(sp.Pos, sp.rapidity(w.C), sp.Angle, sp.Spin, sp.mass(), sp.radius(), sp.Sprite)
+		p.SetProperVelocity(p.ProperVelocity(w.C), w.C)
+	}
+}
+
+// pool returns the name of the pool the particle is added to
+func (p ScenarioParticle) pool() string {
+	if p.Pool == "" {
+		return "asteroids"
+	}
+
+	return p.Pool
+}
+
+// radius returns the radius of the particle, 1 if it was left out
+func (p ScenarioParticle) radius() float64 {
+	if p.Radius == 0 {
+		return 1
+	}
+
+	return p.Radius
+}
+
+// mass returns the mass of the particle, the area of its disc if it was left out
+func (p ScenarioParticle) mass() float64 {
+	if p.Mass == 0 {
+		return math.Pi * math.Pow(p.radius(), 2)
+	}
+
+	return p.Mass
+}
+
+// rapidity returns the rapidity of the particle when the speed of light is c
+func (p ScenarioParticle) rapidity(c float64) Vector {
+	if p.Beta != (Vector{}) {
+		return p.Beta.SetMag(c * math.Atanh(p.Beta.Mag()))
+	}
+
+	return p.Rapidity
+}
+
+// validate returns an error describing everything invalid about the particle, or nil if it is valid
+func (p ScenarioParticle) validate(name string) error {
+	var errs []error
+
+	if p.Rapidity != (Vector{}) && p.Beta != (Vector{}) {
+		errs = append(errs, fmt.Errorf("%s: only one of rapidity and beta can be given", name))
+	}
+
+	if p.Beta.Mag() >= 1 {
+		errs = append(errs, fmt.Errorf("%s: must be slower than light, got %gc", name, p.Beta.Mag()))
+	}
+
+	if p.Mass < 0 || p.Radius < 0 {
+		errs = append(errs, fmt.Errorf("%s: mass and radius must not be negative, got %g and %g", name, p.Mass, p.Radius))
+	}
+
+	return errors.Join(errs...)
+}
+
+// validate returns an error describing everything invalid about the condition, or nil if it is valid
+func (c ScenarioCondition) validate(name string) error {
+	var errs []error
+
+	if c.Time < 0 {
+		errs = append(errs, fmt.Errorf("%s: time must not be negative, got %s", name, time.Duration(c.Time)))
+	}
+
+	if c.Reach != nil && c.Radius <= 0 {
+		errs = append(errs, fmt.Errorf("%s: the radius of the position to reach must be positive, got %g", name, c.Radius))
+	}
+
+	return errors.Join(errs...)
+}
+
+// met returns whether any part of the condition is met in w
+func (c ScenarioCondition) met(w *World) bool {
+	if c.Cleared && len(w.Asteroids.Active()) == 0 {
+		return true
+	}
+
+	if c.Time > 0 && w.Time() >= c.Time.Seconds() {
+		return true
+	}
+
+	return c.Reach != nil && w.Ship.Pos.Dist(*c.Reach) <= c.Radius
+}
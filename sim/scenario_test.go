@@ -0,0 +1,189 @@
+package sim
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScenarioExamples(t *testing.T) {
+	paths, err := filepath.Glob("../scenarios/*.json")
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("found no example scenarios: %v", err)
+	}
+
+	for _, path := range paths {
+		sc, err := LoadScenario(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cfg, err := sc.Apply(DefaultConfig())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The example scenarios all end by themselves
+		w := NewScenarioWorld(1, cfg, sc)
+		for i := 0; i < 60*cfg.PhysicsRate && !w.Ended; i++ {
+			w.Step(Input{})
+		}
+
+		if !w.Won {
+			t.Fatalf("%s: scenario was not won", path)
+		}
+	}
+}
+
+func TestScenarioWorld(t *testing.T) {
+	sc, err := DecodeScenario(strings.NewReader(`{
+		"name": "TEST",
+		"settings": {"c": 10, "drag": 0},
+		"ship": {"pos": {"x": 5, "y": 0}, "beta": {"x": 0, "y": 0.6}},
+		"particles": [
+			{"pos": {"x": -20, "y": 0}, "beta": {"x": 0.8, "y": 0}, "radius": 2},
+			{"pos": {"x": 0, "y": 30}, "rapidity": {"x": 0, "y": 3}, "sprite": 1},
+			{"pool": "explosion", "pos": {"x": 0, "y": -30}}
+		],
+		"win": {"cleared": true},
+		"lose": {"time": "1s"},
+		"camera": {"beta": {"x": 0.5, "y": 0}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := sc.Apply(DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.C != 10 || cfg.Drag != 0 || cfg.Thrust != DefaultConfig().Thrust {
+		t.Fatalf("settings were not applied on top of the config: %+v", cfg)
+	}
+
+	w := NewScenarioWorld(1, cfg, sc)
+
+	if w.Ship.Pos != (Vector{5, 0}) || !approxEqual(w.Ship.Vel.Y, 6, 1) {
+		t.Fatalf("ship at %v with velocity %v", w.Ship.Pos, w.Ship.Vel)
+	}
+
+	asteroids := w.Asteroids.Active()
+	if len(asteroids) != 2 || len(w.Explosion.Active()) != 1 {
+		t.Fatalf("got %d asteroids and %d explosion particles", len(asteroids), len(w.Explosion.Active()))
+	}
+
+	if a := asteroids[0]; !approxEqual(a.Vel.X, 8, 1) || !approxEqual(a.Mass, 4*3.141592653589793, 1) {
+		t.Fatalf("first asteroid has velocity %v and mass %g", a.Vel, a.Mass)
+	}
+
+	if a := asteroids[1]; a.Rap != (Vector{0, 3}) || a.Vel.Mag() >= w.C {
+		t.Fatalf("second asteroid has rapidity %v and velocity %v", a.Rap, a.Vel)
+	}
+
+	if f := w.Frame(); !approxEqual(f.X, 5, 1) {
+		t.Fatalf("camera frame has velocity %v", f)
+	}
+
+	// The asteroids are never cleared, so the run is lost after a second
+	for i := 0; i < 2*cfg.PhysicsRate && !w.Ended; i++ {
+		w.Step(Input{})
+	}
+
+	if !w.Ended || w.Won || !approxEqual(w.Time(), 1, 1) {
+		t.Fatalf("ended = %v, won = %v at t = %f", w.Ended, w.Won, w.Time())
+	}
+}
+
+func TestScenarioBulletsOverlap(t *testing.T) {
+	// Two bullets overlapping the same big asteroid, and a small asteroid so the run isn't won once it breaks
+	sc, err := DecodeScenario(strings.NewReader(`{
+		"particles": [
+			{"pos": {"x": 20, "y": 0}, "radius": 2},
+			{"pool": "bullets", "pos": {"x": 20, "y": 0.5}, "radius": 0.2},
+			{"pool": "bullets", "pos": {"x": 20, "y": -0.5}, "radius": 0.2},
+			{"pos": {"x": -40, "y": 0}, "sprite": 1}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, _ := sc.Apply(DefaultConfig())
+	w := NewScenarioWorld(1, cfg, sc)
+	w.Step(Input{})
+
+	// The asteroid is only destroyed once, so it breaks into three small asteroids and is only rewarded once
+	if n := len(w.Asteroids.Active()); n != 4 {
+		t.Fatalf("got %d asteroids, want 4", n)
+	}
+
+	if w.Score != 3 || w.Ammo != cfg.Ammo+3 {
+		t.Fatalf("got score %d and ammo %d, want 3 and %d", w.Score, w.Ammo, cfg.Ammo+3)
+	}
+
+	if n := len(w.Bullets.Active()); n != 1 {
+		t.Fatalf("got %d bullets, want the one that missed to be left", n)
+	}
+}
+
+func TestScenarioValidate(t *testing.T) {
+	for _, tc := range []struct {
+		json string
+		want string
+	}{
+		{`{"particles": [{"beta": {"x": 1}}]}`, "slower than light"},
+		{`{"particles": [{"beta": {"x": 0.5}, "rapidity": {"x": 1}}]}`, "only one of rapidity and beta"},
+		{`{"particles": [{"pool": "ships"}]}`, "unknown pool"},
+		{`{"particles": [{"sprite": 2}]}`, "no sprite 2"},
+		{`{"ship": {"pool": "asteroids"}}`, "can't be put in a pool"},
+		{`{"settings": {"c": -1}}`, "speed of light must be positive"},
+		{`{"settings": {"speed": 1}}`, "unknown field"},
+		{`{"win": {"reach": {"x": 1}}}`, "radius of the position to reach"},
+		{`{"camera": {"beta": {"x": 2}}}`, "slower than light"},
+		{`{"asteroids": 3}`, "unknown field"},
+	} {
+		_, err := DecodeScenario(strings.NewReader(tc.json))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: got error %v, want one containing %q", tc.json, err, tc.want)
+		}
+	}
+}
+
+func TestReplayScenario(t *testing.T) {
+	data, err := os.ReadFile("../scenarios/head-on.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sc, err := DecodeScenario(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, _ := sc.Apply(DefaultConfig())
+	r := NewReplay(42, cfg, sc)
+	r.Record(Input{Thrust: 1})
+
+	var buf bytes.Buffer
+	if err := r.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := DecodeReplay(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Scenario == nil || got.Scenario.Name != sc.Name || len(got.Scenario.Particles) != len(sc.Particles) {
+		t.Fatalf("got scenario %+v, want %+v", got.Scenario, sc)
+	}
+
+	// The replayed world is set up the same as the recorded one
+	a, b := NewScenarioWorld(42, cfg, sc), NewScenarioWorld(42, got.Config, got.Scenario)
+	if a.Asteroids.Active()[0].Vel != b.Asteroids.Active()[0].Vel || a.Frame() != b.Frame() {
+		t.Fatal("replayed scenario world differs from the recorded one")
+	}
+}
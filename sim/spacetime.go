@@ -0,0 +1,56 @@
+package sim
+
+// SpacetimeDiagram projects events onto a 1+1D Minkowski diagram along the direction of motion of an observer. The
+// diagram is drawn in the frame the observer moves through, with the current event of the observer at the origin, so
+// the worldline of the observer is tilted by its velocity and so are its lines of simultaneity.
+type SpacetimeDiagram struct {
+	Origin FourVector // The current event of the observer
+	Axis   Vector     // The unit direction in space along which events are plotted
+	Frame  Vector     // The velocity of the observer
+	C      float64    // The speed of light
+}
+
+// NewSpacetimeDiagram returns the diagram for an observer at pos at time t moving with velocity frame. The diagram is
+// plotted along the velocity, or along facing when the observer is at rest.
+func NewSpacetimeDiagram(pos Vector, t float64, frame, facing Vector, c float64) SpacetimeDiagram {
+	axis := facing.Unit()
+	if frame.SqrMag() > 0 {
+		axis = frame.Unit()
+	}
+
+	return SpacetimeDiagram{
+		Origin: NewEvent(t, pos, c),
+		Axis:   axis,
+		Frame:  frame,
+		C:      c,
+	}
+}
+
+// Beta returns the velocity of the observer along the axis as a fraction of c. It is the slope ct/x of the lines of
+// simultaneity of the observer, and the slope x/ct of its worldline.
+func (d SpacetimeDiagram) Beta() float64 {
+	return d.Frame.Dot(d.Axis) / d.C
+}
+
+// Project returns the position of the event in the diagram, with the distance along the axis as X and c·t as Y, both
+// relative to the current event of the observer
+func (d SpacetimeDiagram) Project(e FourVector) Vector {
+	rel := e.Sub(d.Origin)
+
+	return Vector{
+		X: rel.Spatial().Dot(d.Axis),
+		Y: rel.T,
+	}
+}
+
+// Worldline returns the projected samples of the history, oldest first. Every sample is moved by shift first, so that
+// the copy of a particle nearest the observer across the seams of a topology can be plotted.
+func (d SpacetimeDiagram) Worldline(h *History, shift Vector) []Vector {
+	out := make([]Vector, h.Len())
+	for i := range out {
+		s := h.At(i)
+		out[i] = d.Project(NewEvent(s.Time, s.Pos.Add(shift), d.C))
+	}
+
+	return out
+}
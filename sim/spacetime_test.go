@@ -0,0 +1,80 @@
+package sim
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSpacetimeDiagramSimultaneity(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	c := 10.0
+
+	for i := 0; i < 1000; i++ {
+		pos := randUnit(rng).Scl(rng.Float64() * 100)
+		frame := randVelocity(rng, c)
+		d := NewSpacetimeDiagram(pos, rng.Float64()*100, frame, Vector{1, 0}, c)
+		boost := NewBoost(frame, c)
+
+		// An event along the axis that the observer sees as simultaneous with its current event
+		x := (rng.Float64()*2 - 1) * 50
+		rel := boost.Inverse().Event(FourVector{X: d.Axis.X * x, Y: d.Axis.Y * x})
+		p := d.Project(d.Origin.Add(rel))
+
+		// It lies on the line of simultaneity of the observer
+		if !approxEqual(p.Y, d.Beta()*p.X, 1e3) {
+			t.Fatalf("simultaneous event projected to %v, not on the line ct = %g x", p, d.Beta())
+		}
+
+		// An event the observer passes through at an earlier time lies on its worldline
+		tau := rng.Float64() * 10
+		rel = boost.Inverse().Event(FourVector{T: -c * tau})
+		p = d.Project(d.Origin.Add(rel))
+
+		if !approxEqual(p.X, d.Beta()*p.Y, 1e3) || p.Y >= 0 {
+			t.Fatalf("past event of the observer projected to %v, not on the worldline x = %g ct", p, d.Beta())
+		}
+	}
+}
+
+func TestSpacetimeDiagramWorldline(t *testing.T) {
+	c := 10.0
+	d := NewSpacetimeDiagram(Vector{5, 5}, 2, Vector{}, Vector{0, -1}, c)
+
+	// At rest the diagram is plotted along the direction the observer is facing
+	if d.Axis != (Vector{0, -1}) || d.Beta() != 0 {
+		t.Fatalf("got axis %v and beta %g for an observer at rest", d.Axis, d.Beta())
+	}
+
+	h := NewHistory(3)
+	h.Record(HistorySample{Time: 0, Pos: Vector{5, 5}})
+	h.Record(HistorySample{Time: 1, Pos: Vector{5, 0}})
+	h.Record(HistorySample{Time: 2, Pos: Vector{7, -5}})
+
+	want := []Vector{{0, -20}, {5, -10}, {10, 0}}
+	for i, p := range d.Worldline(h, Vector{}) {
+		if p != want[i] {
+			t.Fatalf("sample %d projected to %v, want %v", i, p, want[i])
+		}
+	}
+}
+
+func TestSpacetimeDiagramWorldlineSeam(t *testing.T) {
+	c := 10.0
+	torus := Torus{Size: 100}
+	d := NewSpacetimeDiagram(Vector{49, 0}, 1, Vector{5, 0}, Vector{0, -1}, c)
+
+	// A particle just across the seam from the observer, which has crossed it itself
+	h := NewHistory(2)
+	h.Record(HistorySample{Time: 0, Pos: Vector{-52, 0}})
+	h.Record(HistorySample{Time: 1, Pos: Vector{-49, 0}})
+
+	obs, pos := d.Origin.Spatial(), h.At(1).Pos
+	shift := obs.Add(torus.Offset(obs, pos)).Sub(pos)
+
+	want := []Vector{{-1, -10}, {2, 0}}
+	for i, p := range d.Worldline(h, shift) {
+		if !approxEqual(p.X, want[i].X, 1e3) || !approxEqual(p.Y, want[i].Y, 1e3) {
+			t.Fatalf("sample %d projected to %v, want %v", i, p, want[i])
+		}
+	}
+}
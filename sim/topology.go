@@ -0,0 +1,205 @@
+package sim
+
+import (
+	"github.com/charmbracelet/log"
+	"math"
+)
+
+// Topology is the shape of the space the world is in, which decides what happens to particles that fly far away
+type Topology interface {
+	// Confine moves the particles of the world that have left the space back into it, once per step
+	Confine(w *World)
+
+	// Offset returns the shortest displacement from a to b
+	Offset(a, b Vector) Vector
+
+	// Images returns how far a point at pos has to be moved to reach each of its other copies that are within reach
+	// of the edges of the space, for finding collisions across the seams. Spaces without seams return none.
+	Images(pos Vector, reach float64) []Vector
+
+	// Ghosts returns how far a point at offset from an observer has to be moved to reach each of its other copies that
+	// are within reach of the observer along both axes, for drawing particles across the seams. Spaces without seams
+	// return none.
+	Ghosts(offset Vector, reach float64) []Vector
+}
+
+// Unbounded is an infinite plane, particles that fly away drift forever
+type Unbounded struct{}
+
+// Confine does nothing, the plane has no edges
+func (Unbounded) Confine(*World) {}
+
+// Offset returns the displacement from a to b
+func (Unbounded) Offset(a, b Vector) Vector {
+	return b.Sub(a)
+}
+
+// Images returns no copies, the plane has no seams
+func (Unbounded) Images(Vector, float64) []Vector {
+	return nil
+}
+
+// Ghosts returns no copies, the plane has no seams
+func (Unbounded) Ghosts(Vector, float64) []Vector {
+	return nil
+}
+
+// SpawnRing keeps the asteroids within Radius of the ship, so that the density of the field stays the same in long
+// runs. An asteroid that drifts further away is moved to the opposite side of the ring, still moving the same way, so
+// that it comes back towards the ship.
+type SpawnRing struct {
+	Unbounded // Apart from the asteroids being recycled, space is an infinite plane
+
+	Radius float64 // The furthest an asteroid can be from the ship
+}
+
+// Confine moves the asteroids further than the radius from the ship to the opposite side of the ring
+func (r SpawnRing) Confine(w *World) {
+	for i, p := range w.Asteroids.particles {
+		if !w.Asteroids.active[i] {
+			continue
+		}
+
+		offset := p.Pos.Sub(w.Ship.Pos)
+		if offset.Mag() <= r.Radius {
+			continue
+		}
+
+		log.Debug("recycling asteroid", "i", i, "distance", offset.Mag())
+		w.Asteroids.Teleport(i, w.Ship.Pos.Sub(offset.SetMag(r.Radius)))
+	}
+}
+
+// Torus is a square of side Size centred on the origin whose opposite edges are joined, like the screen of classic
+// asteroids. The square is at rest in the rest frame of the asteroid field, so particles are wrapped at the same
+// moment in that frame. A wrapped particle is moved along with its history, so that its worldline carries on across
+// the seam and it is still drawn at its retarded or simultaneous state.
+type Torus struct {
+	Size float64 // The length of the sides of the square
+}
+
+// Offset returns the displacement from a to the nearest copy of b
+func (t Torus) Offset(a, b Vector) Vector {
+	d := b.Sub(a)
+	return d.Add(t.wrap(d))
+}
+
+// Images returns how far a point at pos has to be moved to reach each of its copies in the squares around this one
+// that are within reach of its edges
+func (t Torus) Images(pos Vector, reach float64) []Vector {
+	return t.copies(pos, t.Size/2+reach)
+}
+
+// Ghosts returns how far a point at offset from an observer has to be moved to reach each of its copies that are
+// within reach of the observer
+func (t Torus) Ghosts(offset Vector, reach float64) []Vector {
+	return t.copies(offset, reach)
+}
+
+// copies returns how far a point at pos has to be moved to reach each of its other copies within reach of the origin
+// along both axes
+func (t Torus) copies(pos Vector, reach float64) []Vector {
+	var out []Vector
+
+	n := int(math.Ceil(reach/t.Size)) + 1
+	for i := -n; i <= n; i++ {
+		for j := -n; j <= n; j++ {
+			d := Vector{X: float64(i) * t.Size, Y: float64(j) * t.Size}
+			if c := pos.Add(d); (i != 0 || j != 0) && math.Abs(c.X) <= reach && math.Abs(c.Y) <= reach {
+				out = append(out, d)
+			}
+		}
+	}
+
+	return out
+}
+
+// wrap returns how far a particle at pos has to be moved to be back inside the square
+func (t Torus) wrap(pos Vector) Vector {
+	return Vector{
+		X: -t.Size * math.Floor(pos.X/t.Size+0.5),
+		Y: -t.Size * math.Floor(pos.Y/t.Size+0.5),
+	}
+}
+
+// Confine wraps the ship and every particle that has left the square to the opposite edge
+func (t Torus) Confine(w *World) {
+	if d := t.wrap(w.Ship.Pos); d != (Vector{}) {
+		w.Ship.Pos = w.Ship.Pos.Add(d)
+		w.Ship.PrevPos = w.Ship.PrevPos.Add(d)
+		w.ShipTrail.Shift(d)
+		w.ShipWrap = w.ShipWrap.Add(d)
+	}
+
+	for _, pool := range []*Pool{w.Asteroids, w.Bullets, w.Explosion} {
+		for i, p := range pool.particles {
+			if !pool.active[i] {
+				continue
+			}
+
+			if d := t.wrap(p.Pos); d != (Vector{}) {
+				pool.Translate(i, d)
+			}
+		}
+	}
+}
+
+// seamPairs returns the index pairs of particles from a and b that could be colliding across the seams of the space,
+// which the broad phase misses as it only sees the copies of the particles inside the space. The pairs are found by
+// giving the broad phase copies of the particles in b that are near the edges.
+func seamPairs(a, b []*Particle, bp BroadPhase, t Topology) [][2]int {
+	reach := maxReach(a) + maxReach(b)
+
+	ghosts := make([]*Particle, 0)
+	owners := make([]int, 0)
+
+	for j, q := range b {
+		for _, d := range t.Images(q.Pos, reach) {
+			ghost := *q
+			ghost.Pos = q.Pos.Add(d)
+
+			ghosts = append(ghosts, &ghost)
+			owners = append(owners, j)
+		}
+	}
+
+	if len(ghosts) == 0 {
+		return nil
+	}
+
+	out := make([][2]int, 0)
+	for _, pair := range bp.CrossPairs(a, ghosts) {
+		out = append(out, [2]int{pair[0], owners[pair[1]]})
+	}
+
+	return out
+}
+
+// joinPairs returns the pairs that are in either list without duplicates, sorted by the first and then the second
+// index. If within is true the pairs are between particles of the same list, so pairs of a particle with itself are
+// dropped and the indices of each pair are put in order.
+func joinPairs(pairs, more [][2]int, within bool) [][2]int {
+	if len(more) == 0 {
+		return pairs
+	}
+
+	seen := make(map[[2]int]bool, len(pairs)+len(more))
+	out := make([][2]int, 0, len(pairs)+len(more))
+
+	for _, pair := range append(pairs, more...) {
+		if within {
+			if pair[0] == pair[1] {
+				continue
+			}
+
+			pair = [2]int{min(pair[0], pair[1]), max(pair[0], pair[1])}
+		}
+
+		if !seen[pair] {
+			seen[pair] = true
+			out = append(out, pair)
+		}
+	}
+
+	return sortPairs(out)
+}
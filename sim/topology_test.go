@@ -0,0 +1,174 @@
+package sim
+
+import "testing"
+
+// topologyWorld returns an empty world with the given topology, where the speed of light is 10. It uses the verlet
+// integrator, which steps in the time of the rest frame, so particles move exactly as far as their velocity says.
+func topologyWorld(topology string, size float64) *World {
+	cfg := DefaultConfig()
+	cfg.C = 10
+	cfg.Integrator = "verlet"
+	cfg.Asteroids = 0
+	cfg.Drag = 0
+	cfg.Topology = topology
+	cfg.WorldSize = size
+
+	return NewWorld(1, cfg)
+}
+
+// checkContinuous fails the test if a history jumps further than max between two samples
+func checkContinuous(t *testing.T, name string, h *History, max float64) {
+	t.Helper()
+
+	for i := 1; i < h.Len(); i++ {
+		if d := h.At(i).Pos.Dist(h.At(i - 1).Pos); d > max {
+			t.Fatalf("%s history jumps by %f at t = %f", name, d, h.At(i).Time)
+		}
+	}
+}
+
+func TestTorus(t *testing.T) {
+	w := topologyWorld("torus", 20)
+
+	p := w.SpawnAsteroid(Vector{9, 0}, Vector{5, 0})
+	w.Ship.SetFourMomentum(NewFourMomentum(w.Ship.Mass, Vector{0, -8}, w.C), w.C)
+
+	for i := 0; i < 2*w.Config.PhysicsRate; i++ {
+		w.Step(Input{})
+	}
+
+	// Both have crossed the seam and come back in on the opposite edge
+	if !approxEqual(p.Pos.X, 9+10-20, 10) || p.Pos.Y != 0 {
+		t.Fatalf("asteroid at %v", p.Pos)
+	}
+
+	if !approxEqual(w.Ship.Pos.Y, -16+20, 10) {
+		t.Fatalf("ship at %v", w.Ship.Pos)
+	}
+
+	// Their worldlines carry on across the seam, so they are still drawn at their retarded positions
+	checkContinuous(t, "asteroid", w.Asteroids.History(0), 1)
+	checkContinuous(t, "ship", w.ShipTrail, 1)
+
+	if _, ok := w.Asteroids.History(0).Retarded(w.Ship.Pos, w.Time(), w.C); !ok {
+		t.Fatal("asteroid has no retarded state")
+	}
+}
+
+func TestSpawnRing(t *testing.T) {
+	w := topologyWorld("ring", 30)
+
+	// An asteroid flying away from the ship comes back in on the other side of the ring
+	p := w.SpawnAsteroid(Vector{29, 0}, Vector{5, 0})
+	near := w.SpawnAsteroid(Vector{0, 10}, Vector{})
+
+	for i := 0; i < w.Config.PhysicsRate; i++ {
+		w.Step(Input{})
+	}
+
+	if p.Pos.Mag() > 30 || p.Pos.X > -20 || !approxEqual(p.Vel.X, 5, 1) {
+		t.Fatalf("asteroid at %v moving at %v", p.Pos, p.Vel)
+	}
+
+	// It is drawn from where it was recycled to rather than flying across the field
+	checkContinuous(t, "asteroid", w.Asteroids.History(0), 1)
+
+	if near.Pos != (Vector{0, 10}) {
+		t.Fatalf("asteroid inside the ring moved to %v", near.Pos)
+	}
+}
+
+func TestUnbounded(t *testing.T) {
+	w := topologyWorld("unbounded", 30)
+
+	p := w.SpawnAsteroid(Vector{29, 0}, Vector{5, 0})
+	for i := 0; i < w.Config.PhysicsRate; i++ {
+		w.Step(Input{})
+	}
+
+	if !approxEqual(p.Pos.X, 34, 10) {
+		t.Fatalf("asteroid at %v", p.Pos)
+	}
+}
+
+func TestTorusOffset(t *testing.T) {
+	torus := Torus{Size: 20}
+
+	for _, tc := range []struct{ a, b, want Vector }{
+		{Vector{9, 0}, Vector{-9, 0}, Vector{2, 0}},
+		{Vector{-9, -9}, Vector{9, 9}, Vector{-2, -2}},
+		{Vector{1, 2}, Vector{3, 5}, Vector{2, 3}},
+	} {
+		if got := torus.Offset(tc.a, tc.b); !approxEqual(got.X, tc.want.X, 1) || !approxEqual(got.Y, tc.want.Y, 1) {
+			t.Errorf("offset from %v to %v: got %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+
+	// Only points near the edges have copies near the square
+	if n := len(torus.Images(Vector{0, 0}, 1)); n != 0 {
+		t.Fatalf("got %d copies of the centre", n)
+	}
+
+	if n := len(torus.Images(Vector{9.5, -9.5}, 1)); n != 3 {
+		t.Fatalf("got %d copies of a corner, want 3", n)
+	}
+
+	// An observer sees copies of every point when the square is smaller than what it can see
+	if n := len(torus.Ghosts(Vector{0, 0}, 45)); n != 24 {
+		t.Fatalf("got %d ghosts, want 24", n)
+	}
+}
+
+func TestTorusCollisions(t *testing.T) {
+	torus := Torus{Size: 20}
+	c := 10.0
+
+	for name, bp := range map[string]BroadPhase{"brute": BruteForce{}, "hash": SpatialHash{}, "sweep": SweepAndPrune{}} {
+		// Two asteroids on either side of the seam moving towards each other across it
+		p := &Particle{Pos: Vector{-9.8, 0}, Mass: 1, Radius: 1}
+		q := &Particle{Pos: Vector{9.8, 0}, Mass: 1, Radius: 1}
+		p.SetFourMomentum(NewFourMomentum(1, Vector{-2, 0}, c), c)
+		q.SetFourMomentum(NewFourMomentum(1, Vector{2, 0}, c), c)
+
+		if p.CheckCollision(q, Vector{}, c, Unbounded{}) || !p.CheckCollision(q, Vector{}, c, torus) {
+			t.Fatalf("%s: asteroids should only touch across the seam", name)
+		}
+
+		SolveCollisions([]*Particle{p, q}, Vector{}, c, bp, Elastic{}, torus)
+
+		// They bounce off each other and are pushed apart across the seam, without being moved to the same side
+		if p.Vel.X <= 0 || q.Vel.X >= 0 {
+			t.Fatalf("%s: got velocities %v and %v after colliding", name, p.Vel, q.Vel)
+		}
+
+		if d := torus.Offset(p.Pos, q.Pos).Mag(); !approxEqual(d, p.ScaledRadius()+q.ScaledRadius(), 1) || p.Pos.X > -9 || q.Pos.X < 9 {
+			t.Fatalf("%s: asteroids at %v and %v are %f apart", name, p.Pos, q.Pos, d)
+		}
+	}
+
+	// Bullets hit asteroids across the seam, and the closest asteroid is found across it
+	w := topologyWorld("torus", 20)
+	w.SpawnAsteroid(Vector{9.8, 0}, Vector{})
+	w.Bullets.Activate(Vector{-9.9, 0}, Vector{}, 0, 0, 1, 1, 0)
+
+	if n := len(w.Bullets.PoolCollisions(w.Asteroids, Vector{}, w.C)); n != 1 {
+		t.Fatalf("got %d bullet hits across the seam", n)
+	}
+
+	if got := w.Asteroids.Closest(Vector{-8, 0}); !approxEqual(got.X, -10.2, 1) {
+		t.Fatalf("closest asteroid at %v, want its copy at -10.2", got)
+	}
+}
+
+func TestScenarioTopology(t *testing.T) {
+	sc := &Scenario{Settings: []byte(`{"topology": "torus", "worldSize": 30}`)}
+
+	cfg, err := sc.Apply(DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if w := NewScenarioWorld(1, cfg, sc); w.Topology != (Torus{Size: 30}) || w.Asteroids.topology != w.Topology {
+		t.Fatalf("scenario world has topology %+v", w.Topology)
+	}
+}
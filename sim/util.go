@@ -0,0 +1,102 @@
+package sim
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Gamma returns the lorentz factor for the given velocity and speed of light
+func Gamma(v, c float64) float64 {
+	return 1.0 / math.Sqrt(1.0-(v*v)/(c*c))
+}
+
+// DopplerFactor returns the ratio of observed to emitted frequency of light from a source moving with velocity v
+// relative to the observer, where n is the unit vector from the observer towards where the source is seen.
+// Values above 1 are blueshifted and values below 1 are redshifted.
+func DopplerFactor(v, n Vector, c float64) float64 {
+	return 1.0 / (Gamma(v.Mag(), c) * (1.0 + v.Dot(n)/c))
+}
+
+// getDrag is used to calculate a force due to air resistance
+// It is used to make the simulation closer to the original arcade game
+func getDrag(v Vector, k float64) Vector {
+	return v.SetMag(-k * v.SqrMag())
+}
+
+// mapRange is used to map a value from one range to another
+func mapRange(v, minIn, maxIn, minOut, maxOut float64) float64 {
+	return (v-minIn)/(maxIn-minIn)*(maxOut-minOut) + minOut
+}
+
+// newAsteroidPool returns a new pool of n asteroids, randomised using the given random source.
+func newAsteroidPool(rng *rand.Rand, n int, area float64) *Pool {
+	// Create a new pool
+	p := NewPool(n)
+
+	// Populate the pool with n asteroids
+	for i := 0; i < n; i++ {
+		// 2 in 5 asteroids should be a small asteroid
+		if rng.Int63n(5) < 2 {
+			radius := rng.Float64()*0.5 + 0.5
+			mass := math.Pi * math.Pow(radius, 2)
+
+			p.particles[i] = &Particle{
+				Pos:    randUnit(rng).Scl(area),
+				Rap:    randUnit(rng).Scl(0.5),
+				Vel:    Vector{},
+				Acc:    Vector{},
+				AngPos: rng.Float64() * 2 * math.Pi,
+				AngVel: rng.Float64()*0.25 - 0.125,
+				Mass:   mass,
+				Radius: radius,
+				Gamma:  1,
+				Clock:  0,
+			}
+
+			p.sprites[i] = 1
+		} else {
+			radius := rng.Float64() + 1
+			mass := math.Pi * math.Pow(radius, 2)
+
+			p.particles[i] = &Particle{
+				Pos:    randUnit(rng).Scl(area),
+				Rap:    randUnit(rng).Scl(0.5),
+				Vel:    Vector{},
+				Acc:    Vector{},
+				AngPos: rng.Float64() * 2 * math.Pi,
+				AngVel: rng.Float64()*0.25 - 0.125,
+				Mass:   mass,
+				Radius: radius,
+				Gamma:  1,
+				Clock:  0,
+			}
+
+			p.sprites[i] = 0
+		}
+
+		p.active[i] = true
+		p.particles[i].SavePrevious()
+	}
+
+	return p
+}
+
+// explode is used to place explosion particles in a pool, randomised using the given random source
+func explode(rng *rand.Rand, pool *Pool, target *Particle) {
+	radius := rng.Float64()*0.5 + 0.5
+	mass := math.Pi * math.Pow(radius, 2)
+
+	n := 16
+
+	for i := 0; i < n; i++ {
+		pool.Activate(
+			target.Pos.Add(Vector{1, 0}.Rotate(float64(i)*math.Pi*2.0/float64(n)).Scl(0.2)),
+			target.Rap.Add(Vector{1, 0}.Rotate(float64(i)*math.Pi*2.0/float64(n)).Scl(0.5)),
+			rng.Float64()*math.Pi*2,
+			rng.Float64()*0.25-0.125,
+			mass, radius,
+			0,
+		)
+	}
+
+}
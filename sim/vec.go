@@ -0,0 +1,328 @@
+package sim
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// randUnit is used to generate a random unit vector from the given random source
+func randUnit(rng *rand.Rand) Vector {
+	a := rng.Float64() * 2 * math.Pi
+
+	return Vector{
+		X: math.Cos(a),
+		Y: math.Sin(a),
+	}
+}
+
+// Vector represents a vector in 2D space
+type Vector struct {
+	X, Y float64
+}
+
+// Add returns the sum of two vectors
+func (v Vector) Add(w Vector) Vector {
+	return Vector{
+		X: v.X + w.X,
+		Y: v.Y + w.Y,
+	}
+}
+
+// Sub returns the difference between two vectors
+func (v Vector) Sub(w Vector) Vector {
+	return Vector{
+		X: v.X - w.X,
+		Y: v.Y - w.Y,
+	}
+}
+
+// Neg returns the negation of a vector
+func (v Vector) Neg() Vector {
+	return Vector{
+		X: -v.X,
+		Y: -v.Y,
+	}
+}
+
+// Abs returns the absolute value of a vector
+func (v Vector) Abs() Vector {
+	return Vector{
+		X: math.Abs(v.X),
+		Y: math.Abs(v.Y),
+	}
+}
+
+// Dot returns the dot product of two vectors
+func (v Vector) Dot(w Vector) float64 {
+	return v.X*w.X + v.Y*w.Y
+}
+
+// Scl returns the scaled vector
+func (v Vector) Scl(s float64) Vector {
+	return Vector{
+		X: v.X * s,
+		Y: v.Y * s,
+	}
+}
+
+// Mul returns element-wise multiplication of two vectors
+func (v Vector) Mul(w Vector) Vector {
+	return Vector{
+		X: v.X * w.X,
+		Y: v.Y * w.Y,
+	}
+}
+
+// Div returns element-wise division of two vectors
+func (v Vector) Div(w Vector) Vector {
+	return Vector{
+		X: v.X / w.X,
+		Y: v.Y / w.Y,
+	}
+}
+
+// Mag returns the magnitude of the vector
+func (v Vector) Mag() float64 {
+	return math.Sqrt(v.X*v.X + v.Y*v.Y)
+}
+
+// SqrMag returns the squared magnitude of the vector
+func (v Vector) SqrMag() float64 {
+	return v.X*v.X + v.Y*v.Y
+}
+
+// Dist returns the Euclidean distance between two vectors
+func (v Vector) Dist(w Vector) float64 {
+	return v.Sub(w).Mag()
+}
+
+// SqrDist returns the squared Euclidean distance between two vectors
+func (v Vector) SqrDist(w Vector) float64 {
+	return v.Sub(w).SqrMag()
+}
+
+// Unit returns the unit vector of the vector
+func (v Vector) Unit() Vector {
+	return v.Scl(1 / v.Mag())
+}
+
+// Angle returns the angle of the vector in radians
+func (v Vector) Angle() float64 {
+	return math.Atan2(v.Y, v.X)
+}
+
+// SetMag returns a vector in the same direction with the given magnitude
+func (v Vector) SetMag(s float64) Vector {
+	if v.Mag() == 0 {
+		return v
+	}
+
+	return v.Scl(s / v.Mag())
+}
+
+func (v Vector) Rotate(theta float64) Vector {
+	return Vector{
+		X: v.X*math.Cos(theta) - v.Y*math.Sin(theta),
+		Y: v.X*math.Sin(theta) + v.Y*math.Cos(theta),
+	}
+}
+
+// ToUniform returns the vector as a valid shader uniform object
+func (v Vector) ToUniform() [2]float64 {
+	return [2]float64{v.X, v.Y}
+}
+
+// String returns a string representation of the vector
+func (v Vector) String() string {
+	return fmt.Sprintf("(%.2f, %.2f)", v.X, v.Y)
+}
+
+// AngleBetween returns the angle between two vectors in radians
+func (v Vector) AngleBetween(w Vector) float64 {
+	if v.X < w.X {
+		return -math.Acos(Vector{0, -1}.Dot(w.Sub(v).Unit()))
+	} else {
+		return math.Acos(Vector{0, -1}.Dot(w.Sub(v).Unit()))
+	}
+}
+
+// FourVector represents an event or displacement in spacetime, the time component is stored as c·t so that every
+// component has units of distance. The game is two dimensional, so the z component is always zero and omitted.
+type FourVector struct {
+	T, X, Y float64
+}
+
+// NewEvent returns the event at time t and position pos
+func NewEvent(t float64, pos Vector, c float64) FourVector {
+	return FourVector{
+		T: c * t,
+		X: pos.X,
+		Y: pos.Y,
+	}
+}
+
+// Add returns the sum of two four-vectors
+func (v FourVector) Add(w FourVector) FourVector {
+	return FourVector{
+		T: v.T + w.T,
+		X: v.X + w.X,
+		Y: v.Y + w.Y,
+	}
+}
+
+// Sub returns the difference between two four-vectors
+func (v FourVector) Sub(w FourVector) FourVector {
+	return FourVector{
+		T: v.T - w.T,
+		X: v.X - w.X,
+		Y: v.Y - w.Y,
+	}
+}
+
+// Scl returns the scaled four-vector
+func (v FourVector) Scl(s float64) FourVector {
+	return FourVector{
+		T: v.T * s,
+		X: v.X * s,
+		Y: v.Y * s,
+	}
+}
+
+// Dot returns the Minkowski inner product of two four-vectors, using the (+, -, -) signature
+func (v FourVector) Dot(w FourVector) float64 {
+	return v.T*w.T - v.X*w.X - v.Y*w.Y
+}
+
+// Spatial returns the spatial part of the four-vector
+func (v FourVector) Spatial() Vector {
+	return Vector{
+		X: v.X,
+		Y: v.Y,
+	}
+}
+
+// Time returns the time component of the four-vector
+func (v FourVector) Time(c float64) float64 {
+	return v.T / c
+}
+
+// String returns a string representation of the four-vector
+func (v FourVector) String() string {
+	return fmt.Sprintf("(%.2f; %.2f, %.2f)", v.T, v.X, v.Y)
+}
+
+// FourMomentum represents the energy and momentum of a particle, the time component is stored as E/c
+type FourMomentum struct {
+	FourVector
+}
+
+// NewFourMomentum returns the four-momentum of a particle with the given rest mass and velocity
+func NewFourMomentum(mass float64, vel Vector, c float64) FourMomentum {
+	gamma := Gamma(vel.Mag(), c)
+
+	return FourMomentum{FourVector{
+		T: gamma * mass * c,
+		X: gamma * mass * vel.X,
+		Y: gamma * mass * vel.Y,
+	}}
+}
+
+// Add returns the sum of two four-momenta
+func (p FourMomentum) Add(q FourMomentum) FourMomentum {
+	return FourMomentum{p.FourVector.Add(q.FourVector)}
+}
+
+// Energy returns the energy of the four-momentum
+func (p FourMomentum) Energy(c float64) float64 {
+	return p.T * c
+}
+
+// Mass returns the invariant (rest) mass of the four-momentum
+func (p FourMomentum) Mass(c float64) float64 {
+	return math.Sqrt(math.Max(p.Dot(p.FourVector), 0)) / c
+}
+
+// Velocity returns the velocity of the frame in which the four-momentum has no spatial momentum
+func (p FourMomentum) Velocity(c float64) Vector {
+	return p.Spatial().Scl(c / p.T)
+}
+
+// Boost is a Lorentz boost into the frame of an observer moving with velocity V
+type Boost struct {
+	V     Vector  // The velocity of the frame being boosted into
+	C     float64 // The speed of light
+	Gamma float64 // The lorentz factor of V
+}
+
+// NewBoost returns the boost into the frame of an observer moving with velocity v
+func NewBoost(v Vector, c float64) Boost {
+	return Boost{
+		V:     v,
+		C:     c,
+		Gamma: Gamma(v.Mag(), c),
+	}
+}
+
+// Inverse returns the boost that undoes this boost
+func (b Boost) Inverse() Boost {
+	return Boost{
+		V:     b.V.Neg(),
+		C:     b.C,
+		Gamma: b.Gamma,
+	}
+}
+
+// Event returns the event as seen in the boosted frame
+func (b Boost) Event(e FourVector) FourVector {
+	beta := b.V.Scl(1 / b.C)
+	betaSqr := beta.SqrMag()
+
+	if betaSqr == 0 {
+		return e
+	}
+
+	x := e.Spatial()
+	betaX := beta.Dot(x)
+
+	// Boost the component of x along beta, leaving the perpendicular component unchanged
+	t := b.Gamma * (e.T - betaX)
+	x = x.Add(beta.Scl((b.Gamma-1)*betaX/betaSqr - b.Gamma*e.T))
+
+	return FourVector{
+		T: t,
+		X: x.X,
+		Y: x.Y,
+	}
+}
+
+// Momentum returns the four-momentum as seen in the boosted frame
+func (b Boost) Momentum(p FourMomentum) FourMomentum {
+	return FourMomentum{b.Event(p.FourVector)}
+}
+
+// Velocity returns the velocity as seen in the boosted frame
+func (b Boost) Velocity(u Vector) Vector {
+	// Boost the four-velocity and convert it back into a velocity
+	gamma := Gamma(u.Mag(), b.C)
+	w := b.Event(FourVector{
+		T: gamma * b.C,
+		X: gamma * u.X,
+		Y: gamma * u.Y,
+	})
+
+	return w.Spatial().Scl(b.C / w.T)
+}
+
+// Contract returns the displacement d between two points at rest in the boosted frame, as measured at one time in the
+// original frame. The component of d along V is length contracted by the lorentz factor.
+func (b Boost) Contract(d Vector) Vector {
+	if b.V.SqrMag() == 0 {
+		return d
+	}
+
+	axis := b.V.Unit()
+	along := axis.Scl(axis.Dot(d))
+
+	return d.Sub(along).Add(along.Scl(1 / b.Gamma))
+}
@@ -0,0 +1,92 @@
+package sim
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// tolerance is the relative tolerance used when comparing floats
+const tolerance = 1e-9
+
+// approxEqual returns whether two floats are equal within the tolerance, relative to scale
+func approxEqual(a, b, scale float64) bool {
+	return math.Abs(a-b) <= tolerance*math.Max(scale, 1)
+}
+
+// randVelocity returns a random velocity slower than c
+func randVelocity(rng *rand.Rand, c float64) Vector {
+	return randUnit(rng).Scl(rng.Float64() * 0.99 * c)
+}
+
+func TestBoostPreservesInterval(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 1000; i++ {
+		c := rng.Float64()*100 + 1
+		b := NewBoost(randVelocity(rng, c), c)
+		e := FourVector{T: rng.NormFloat64() * 10, X: rng.NormFloat64() * 10, Y: rng.NormFloat64() * 10}
+
+		got := b.Event(e)
+		if !approxEqual(got.Dot(got), e.Dot(e), b.Gamma*b.Gamma*e.Spatial().SqrMag()) {
+			t.Fatalf("interval changed from %f to %f", e.Dot(e), got.Dot(got))
+		}
+
+		back := b.Inverse().Event(got)
+		if !approxEqual(back.T, e.T, b.Gamma*10) || !approxEqual(back.X, e.X, b.Gamma*10) || !approxEqual(back.Y, e.Y, b.Gamma*10) {
+			t.Fatalf("inverse boost gave %v, want %v", back, e)
+		}
+	}
+}
+
+func TestBoostCollinearVelocity(t *testing.T) {
+	c := 10.0
+	v := 6.0
+	u := -7.0
+
+	got := NewBoost(Vector{v, 0}, c).Velocity(Vector{u, 0})
+	want := (u - v) / (1 - u*v/(c*c))
+
+	if !approxEqual(got.X, want, c) || !approxEqual(got.Y, 0, c) {
+		t.Fatalf("got %v, want (%f, 0)", got, want)
+	}
+
+	// The observer itself is at rest in its own frame
+	if got := NewBoost(Vector{3, 4}, c).Velocity(Vector{3, 4}); !approxEqual(got.Mag(), 0, c) {
+		t.Fatalf("observer velocity in its own frame is %v", got)
+	}
+}
+
+func TestBoostMomentum(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+
+	for i := 0; i < 1000; i++ {
+		c := rng.Float64()*100 + 1
+		mass := rng.Float64()*10 + 0.1
+		u := randVelocity(rng, c)
+		b := NewBoost(randVelocity(rng, c), c)
+
+		p := b.Momentum(NewFourMomentum(mass, u, c))
+		scale := p.T
+
+		if !approxEqual(p.Mass(c), mass, scale) {
+			t.Fatalf("rest mass changed from %f to %f", mass, p.Mass(c))
+		}
+
+		// Boosting the momentum must agree with boosting the velocity
+		want := NewFourMomentum(mass, b.Velocity(u), c)
+		if !approxEqual(p.T, want.T, scale) || !approxEqual(p.X, want.X, scale) || !approxEqual(p.Y, want.Y, scale) {
+			t.Fatalf("got momentum %v, want %v", p, want)
+		}
+	}
+}
+
+func TestBoostContract(t *testing.T) {
+	c := 1.0
+	b := NewBoost(Vector{0, 0.8}, c)
+
+	got := b.Contract(Vector{2, 5})
+	if !approxEqual(got.X, 2, 1) || !approxEqual(got.Y, 5/b.Gamma, 1) {
+		t.Fatalf("got %v, want (2, %f)", got, 5/b.Gamma)
+	}
+}
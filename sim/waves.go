@@ -0,0 +1,117 @@
+package sim
+
+import (
+	"github.com/charmbracelet/log"
+	"math"
+)
+
+// asteroidKind is a kind of asteroid that the waves are made of
+type asteroidKind struct {
+	firstWave int     // The first wave with asteroids of this kind
+	weight    int     // How often asteroids of this kind appear compared to the other kinds
+	minRadius float64 // The smallest radius of the asteroids
+	maxRadius float64 // The largest radius of the asteroids
+	sprite    int     // The sprite of the asteroids, big asteroids break into small asteroids when hit
+}
+
+// asteroidKinds are the kinds of asteroid that the waves are made of, later waves have more kinds
+var asteroidKinds = []asteroidKind{
+	{firstWave: 1, weight: 3, minRadius: 1, maxRadius: 2, sprite: 0},     // Big asteroids
+	{firstWave: 1, weight: 2, minRadius: 0.5, maxRadius: 1, sprite: 1},   // Small asteroids
+	{firstWave: 3, weight: 1, minRadius: 2, maxRadius: 3, sprite: 0},     // Giant asteroids, which barge through the rest
+	{firstWave: 5, weight: 2, minRadius: 0.3, maxRadius: 0.5, sprite: 1}, // Tiny asteroids, which are hard to hit
+}
+
+// Waves spawns a new wave of asteroids once the previous wave has been cleared, each with more, faster and more
+// varied asteroids than the last. The first wave is the asteroid field the world starts with.
+type Waves struct {
+	Number int // The wave the player is on, starting from 1
+
+	cleared   bool // Whether the current wave has been cleared and the next wave is waiting to be spawned
+	clearTick int  // The tick at which the current wave was cleared
+	startTick int  // The tick at which the current wave was spawned
+}
+
+// NewWaves returns a new wave manager on the first wave
+func NewWaves() *Waves {
+	return &Waves{Number: 1}
+}
+
+// Cleared returns whether the current wave has been cleared, and the seconds until the next wave is spawned
+func (wv *Waves) Cleared(w *World) (cleared bool, remaining float64) {
+	if !wv.cleared {
+		return false, 0
+	}
+
+	return true, math.Max(0, w.Config.WaveDelay.Seconds()-w.Since(wv.clearTick))
+}
+
+// Since returns the seconds since the current wave was spawned
+func (wv *Waves) Since(w *World) float64 {
+	return w.Since(wv.startTick)
+}
+
+// update checks whether the current wave has been cleared, and spawns the next wave once the delay has passed
+func (wv *Waves) update(w *World) {
+	if !wv.cleared && len(w.Asteroids.Active()) == 0 {
+		log.Debug("wave cleared", "wave", wv.Number)
+		wv.cleared = true
+		wv.clearTick = w.Tick
+	}
+
+	if wv.cleared && w.Since(wv.clearTick) >= w.Config.WaveDelay.Seconds() {
+		wv.cleared = false
+		wv.startTick = w.Tick
+		wv.Number++
+		wv.spawn(w)
+	}
+}
+
+// spawn adds the asteroids of the current wave on a ring around the ship, and sets the speed of light for the wave
+func (wv *Waves) spawn(w *World) {
+	n := w.Config.Asteroids + w.Config.WaveGrowth*(wv.Number-1)
+	speed := 0.5 + w.Config.WaveSpeed*float64(wv.Number-1)
+
+	log.Debug("spawning wave", "wave", wv.Number, "asteroids", n, "speed", speed)
+
+	// Only the kinds of asteroid that have appeared by this wave are spawned
+	total := 0
+	for _, kind := range asteroidKinds {
+		if kind.firstWave <= wv.Number {
+			total += kind.weight
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		pick := w.rng.Intn(total)
+
+		for _, kind := range asteroidKinds {
+			if kind.firstWave > wv.Number {
+				continue
+			}
+
+			if pick -= kind.weight; pick >= 0 {
+				continue
+			}
+
+			radius := kind.minRadius + w.rng.Float64()*(kind.maxRadius-kind.minRadius)
+			mass := math.Pi * math.Pow(radius, 2)
+
+			w.Asteroids.Activate(
+				w.Ship.Pos.Add(randUnit(w.rng).Scl(w.Config.AsteroidArea*(1+w.rng.Float64()*0.5))),
+				randUnit(w.rng).Scl(speed), // The speed is a rapidity, so the asteroids are always slower than light
+				w.rng.Float64()*2*math.Pi,
+				w.rng.Float64()*0.25-0.125,
+				mass, radius,
+				kind.sprite,
+			)
+
+			break
+		}
+	}
+
+	// Either carry on with the speed of light the last wave ended with, or reset it along the curve
+	if w.Config.WaveC == "reset" {
+		w.beginCLerp(w.Config.C * math.Pow(w.Config.WaveCDecay, float64(wv.Number-1)))
+	}
+}
@@ -0,0 +1,93 @@
+package sim
+
+import "testing"
+
+// clearWave destroys every asteroid in the world
+func clearWave(w *World) {
+	for i := range w.Asteroids.particles {
+		if w.Asteroids.active[i] {
+			w.Asteroids.Deactivate(i)
+		}
+	}
+}
+
+func TestWaves(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Asteroids = 4
+	cfg.WaveGrowth = 3
+	cfg.WaveSpeed = 2
+
+	w := NewWorld(1, cfg)
+	if w.Waves.Number != 1 {
+		t.Fatalf("started on wave %d", w.Waves.Number)
+	}
+
+	// The next wave is only spawned once the delay after clearing a wave has passed
+	clearWave(w)
+	w.Step(Input{})
+
+	if cleared, remaining := w.Waves.Cleared(w); !cleared || remaining <= 0 {
+		t.Fatalf("got cleared = %v with %f seconds remaining", cleared, remaining)
+	}
+
+	for i := 0; i < int(cfg.WaveDelay.Seconds()*float64(cfg.PhysicsRate)); i++ {
+		w.Step(Input{})
+	}
+
+	if w.Waves.Number != 2 || len(w.Asteroids.Active()) != 7 {
+		t.Fatalf("on wave %d with %d asteroids", w.Waves.Number, len(w.Asteroids.Active()))
+	}
+
+	// The asteroids of later waves move faster, and new kinds of asteroid appear
+	for wave := 3; wave <= 6; wave++ {
+		clearWave(w)
+		for i := 0; i < int(cfg.WaveDelay.Seconds()*float64(cfg.PhysicsRate))+2; i++ {
+			w.Step(Input{})
+		}
+
+		if w.Waves.Number != wave {
+			t.Fatalf("on wave %d, want %d", w.Waves.Number, wave)
+		}
+
+		for _, p := range w.Asteroids.Active() {
+			if !approxEqual(p.Rap.Mag(), 0.5+cfg.WaveSpeed*float64(wave-1), 1) {
+				t.Fatalf("wave %d asteroid has rapidity %g", wave, p.Rap.Mag())
+			}
+
+			// Giant asteroids appear from the third wave and tiny asteroids from the fifth
+			if p.Radius > 2 && wave < 3 || p.Radius < 0.5 && wave < 5 {
+				t.Fatalf("wave %d has an asteroid of radius %g", wave, p.Radius)
+			}
+		}
+
+		if n := len(w.Asteroids.Active()); n != cfg.Asteroids+cfg.WaveGrowth*(wave-1) {
+			t.Fatalf("wave %d has %d asteroids", wave, n)
+		}
+	}
+}
+
+func TestWavesSpeedOfLight(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.C = 100
+	cfg.Asteroids = 1
+	cfg.WaveC = "reset"
+	cfg.WaveCDecay = 0.5
+
+	w := NewWorld(1, cfg)
+	w.C = 3
+
+	clearWave(w)
+	for i := 0; i < int((cfg.WaveDelay.Seconds()+cfg.SlowdownDuration.Seconds())*float64(cfg.PhysicsRate))+2; i++ {
+		w.Step(Input{})
+	}
+
+	// The speed of light is reset to half of its starting value for the second wave
+	if w.Waves.Number != 2 || w.C != 50 {
+		t.Fatalf("on wave %d with c = %g", w.Waves.Number, w.C)
+	}
+
+	// Sandboxes and scenarios have no waves
+	if NewSandbox(1, cfg).Waves != nil || NewScenarioWorld(1, cfg, &Scenario{}).Waves != nil {
+		t.Fatal("sandboxes and scenarios should have no waves")
+	}
+}
@@ -0,0 +1,476 @@
+// Package sim is the headless simulation of the game: the world, the physics of its particles, and the configs,
+// replays and scenarios it is set up from. It has no dependency on the window or the renderer.
+package sim
+
+import (
+	"github.com/charmbracelet/log"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Defining the constants used in the simulation.
+const (
+	// How fast the ship rotates in radians per second
+	rotateSpeed float64 = 3
+
+	// The scale factor of the physics engine
+	physScale float64 = 0.3
+
+	// How far back the history of particles is kept
+	historyDuration = time.Second * 5
+)
+
+// Input is the player's input for a single simulation step
+type Input struct {
+	Thrust float64 // How hard the ship is thrusting, from 0 to 1
+	Rotate float64 // How fast the ship is rotating clockwise, from -1 to 1
+	Fire   bool    // Whether a bullet is fired this step
+	Escape bool    // Whether the player has quit the run
+}
+
+// World is the headless simulation of the game, it has no dependency on the window or the renderer
+type World struct {
+	// Particles
+	Ship      *Particle // The ship particle
+	ShipTrail *History  // The history of the ship, for drawing its worldline
+	ShipWrap  Vector    // How far the ship has been moved by wrapping around the topology, so the starfield doesn't jump
+	Asteroids *Pool     // The asteroids pool
+	Bullets   *Pool     // The bullets pool
+	Explosion *Pool     // The explosion particles pool
+
+	// Game state
+	Thrusting bool // Whether the ship is thrusting
+	Health    int  // The health of the ship
+	Ammo      int  // The amount of ammo the ship has
+	Score     int  // The player's score
+	Ended     bool // Whether the run has ended
+	EndTick   int  // The tick at which the run ended
+	Sandbox   bool // Whether the world is a sandbox, without health, ammo, score or the speed of light being lowered
+
+	Waves    *Waves    // The waves of asteroids, nil if a new wave is never spawned
+	Scenario *Scenario // The scenario the world was set up from, nil for a normal run
+	Won      bool      // Whether the scenario was won
+
+	// Physical constants
+	C  float64 // The speed of light
+	Dt float64 // The seconds per tick
+
+	// Interpolation variables
+	cLerpInitial float64       // The initial value of the speed of light when interpolating
+	cLerpTarget  float64       // The target value of the speed of light when interpolating
+	cLerpStart   int           // The tick at which interpolating the speed of light started
+	cLerpTime    time.Duration // How long to interpolate the speed of light
+	cLerp        bool          // Whether the speed of light is interpolating
+
+	InvincibilityStart    int           // The tick at which the invincibility started
+	invincibilityDuration time.Duration // The duration of the invincibility
+	Invincibility         bool          // Whether the ship is invincible
+
+	Clock    float64 // The time from the point of view of an observer at rest with respect to the asteroid field
+	MaxGamma float64 // The highest lorentz factor the ship has reached
+
+	Config     Config     // The settings the world was created with, the sandbox changes the thrust and drag live
+	integrator Integrator // How the motion of the particles is integrated
+	Topology   Topology   // The shape of space, which decides what happens to particles that fly far away
+
+	// Determinism
+	Seed int64      // The seed the world was created with
+	rng  *rand.Rand // The random source of the simulation
+	Tick int        // The number of steps simulated so far
+}
+
+// NewWorld returns a new world ready for a run to begin, using the settings in cfg. Two worlds created with the same
+// seed and config and stepped with the same inputs will always be in the same state.
+func NewWorld(seed int64, cfg Config) *World {
+	log.Debug("creating new world", "seed", seed)
+
+	w := new(World)
+	w.Config = cfg
+
+	// Initialise the random source
+	w.Seed = seed
+	w.rng = rand.New(rand.NewSource(seed))
+
+	// Initialise the speed of light and the tick length
+	w.C = cfg.C
+	w.Dt = 1 / float64(cfg.PhysicsRate)
+
+	// Initialise the score and health
+	w.Health = cfg.Health
+	w.Score = 0
+	w.Ammo = cfg.Ammo
+
+	// The first wave is the asteroid field the world starts with
+	if cfg.Waves {
+		w.Waves = NewWaves()
+	}
+
+	w.cLerpTime = time.Duration(cfg.SlowdownDuration)
+	w.invincibilityDuration = time.Duration(cfg.Invincibility)
+
+	// The config has been validated, so the names are known to be valid
+	model, _ := cfg.collisionModel()
+	bp, _ := cfg.broadPhase()
+	w.integrator, _ = cfg.integrator()
+	w.Topology, _ = cfg.topology()
+
+	// Initialize the ship
+	w.Ship = new(Particle)
+	w.Ship.Mass = 1
+	w.Ship.Radius = 1
+	w.ShipTrail = NewHistory(int(historyDuration.Seconds()/w.Dt) + 1)
+
+	log.Debug("initialising asteroids pool")
+
+	// Initialize the asteroids
+	w.Asteroids = newAsteroidPool(w.rng, cfg.Asteroids, cfg.AsteroidArea).
+		SetBroadPhase(bp).                  // Use the configured broad phase to find collisions
+		SetCollisionModel(model).           // Use the configured collision model
+		SetIntegrator(w.integrator).        // Use the configured integrator
+		SetTopology(w.Topology).            // Collide across the seams of the configured topology
+		TrackHistory(historyDuration, w.Dt) // Keep a history of the asteroids for drawing them at their retarded positions
+
+	log.Debug("initialising bullets pool")
+
+	// Initialize the bullets
+	w.Bullets = NewPool(256).
+		EnforceLifetime(time.Duration(cfg.BulletLifetime)). // Enforce the configured bullet lifetime
+		SetBroadPhase(bp).                                  // Use the configured broad phase to find hits on asteroids
+		SetIntegrator(w.integrator).                        // Use the configured integrator
+		SetTopology(w.Topology).                            // Hit asteroids across the seams of the configured topology
+		TrackHistory(historyDuration, w.Dt).                // Keep a history of the bullets for drawing them at their retarded positions
+		DisableCollision()                                  // Disable collision between bullets
+
+	log.Debug("initialising explosion pool")
+
+	// Initialize the explosion particles
+	w.Explosion = NewPool(256).
+		EnforceLifetime(time.Duration(cfg.ExplosionLifetime)). // Enforce the configured explosion lifetime
+		FadeOverLifetime().                                    // Fade out the explosion particles over the lifetime of the particle
+		SetIntegrator(w.integrator).                           // Use the configured integrator
+		TrackHistory(historyDuration, w.Dt).                   // Keep a history of the explosions for drawing them at their retarded positions
+		DisableCollision()                                     // Disable collision between explosions
+
+	log.Debug("all pools initialised")
+
+	return w
+}
+
+// NewSandbox returns a new world for experimenting in, using the settings in cfg. The sandbox starts without any
+// asteroids, the ship can't be damaged or run out of ammo, and the speed of light only changes when it is set.
+func NewSandbox(seed int64, cfg Config) *World {
+	cfg.Asteroids = 0
+
+	w := NewWorld(seed, cfg)
+	w.Sandbox = true
+	w.Waves = nil
+
+	return w
+}
+
+// NewScenarioWorld returns a new world set up from the scenario, using the settings in cfg, which should already
+// have the settings of the scenario applied. No random asteroids are added, only the particles of the scenario.
+func NewScenarioWorld(seed int64, cfg Config, sc *Scenario) *World {
+	log.Debug("creating scenario world", "name", sc.Name)
+
+	cfg.Asteroids = 0
+
+	w := NewWorld(seed, cfg)
+	w.Scenario = sc
+	w.Sandbox = sc.Sandbox
+	w.Waves = nil // The scenario decides how the run ends
+	sc.populate(w)
+
+	return w
+}
+
+// Frame returns the velocity of the frame the world is drawn in, which is the frame of the ship unless the scenario
+// sets a frame for the camera
+func (w *World) Frame() Vector {
+	if w.Scenario != nil && w.Scenario.Camera.Beta != nil {
+		return w.Scenario.Camera.Beta.Scl(w.C)
+	}
+
+	return w.Ship.Vel
+}
+
+// SetC sets the speed of light. The rapidities of the particles are kept, so nothing ends up faster than light.
+func (w *World) SetC(c float64) {
+	w.setC(c)
+	w.cLerp = false
+}
+
+// setC changes the speed of light, re-deriving the velocities of the ship and the particles from their rapidities
+func (w *World) setC(c float64) {
+	w.C = c
+	w.Ship.ChangeC(c)
+	w.Asteroids.ChangeC(c)
+	w.Bullets.ChangeC(c)
+	w.Explosion.ChangeC(c)
+}
+
+// SpawnAsteroid adds a big asteroid at pos moving with velocity vel, which must be slower than light
+func (w *World) SpawnAsteroid(pos, vel Vector) *Particle {
+	radius := w.rng.Float64() + 1
+	mass := math.Pi * math.Pow(radius, 2)
+
+	p := w.Asteroids.Activate(pos, Vector{}, w.rng.Float64()*2*math.Pi, w.rng.Float64()*0.25-0.125, mass, radius, 0)
+	p.SetFourMomentum(NewFourMomentum(p.Mass, vel, w.C), w.C)
+
+	return p
+}
+
+// beginCLerp begins interpolating the speed of light towards c
+func (w *World) beginCLerp(c float64) {
+	w.cLerpInitial = w.C
+	w.cLerpTarget = c
+	w.cLerpStart = w.Tick
+	w.cLerp = true
+}
+
+// End ends the run, the world keeps simulating the remaining particles
+func (w *World) End() {
+	log.Debug("ending run")
+
+	w.Ended = true
+	w.EndTick = w.Tick
+}
+
+// Time returns the simulation time in seconds
+func (w *World) Time() float64 {
+	return float64(w.Tick) * w.Dt
+}
+
+// Since returns the simulation time in seconds that has passed since the given tick
+func (w *World) Since(tick int) float64 {
+	return float64(w.Tick-tick) * w.Dt
+}
+
+// Step advances the simulation by a single tick using the given input. The tick is split into substeps when
+// particles move far compared to their size, so that fast particles don't pass through each other.
+func (w *World) Step(in Input) {
+	w.Tick++
+
+	// Keep the state at the start of the step for interpolating when drawing
+	w.Ship.SavePrevious()
+	w.Asteroids.SavePrevious()
+	w.Bullets.SavePrevious()
+	w.Explosion.SavePrevious()
+
+	ended := w.Ended
+	n := w.substeps()
+	h := w.Dt / float64(n)
+
+	for i := 0; i < n; i++ {
+		w.substep(in, h)
+
+		// Firing and quitting only happen once per step
+		in.Fire = false
+		in.Escape = false
+	}
+
+	// Bring the particles that have left the space back into it, before their new states are recorded
+	w.Topology.Confine(w)
+
+	// Record the new states of the particles
+	w.ShipTrail.Record(w.Ship.Sample(w.Time()))
+	w.Asteroids.RecordHistory()
+	w.Bullets.RecordHistory()
+	w.Explosion.RecordHistory()
+
+	// Spawn the next wave once the current wave has been cleared
+	if !w.Ended && w.Waves != nil {
+		w.Waves.update(w)
+	}
+
+	// End the run once the scenario has been won or lost
+	if !w.Ended && w.Scenario != nil {
+		if w.Scenario.Win.met(w) {
+			log.Debug("scenario won")
+			w.Won = true
+			w.End()
+		} else if w.Scenario.Lose.met(w) {
+			log.Debug("scenario lost")
+			w.End()
+		}
+	}
+
+	// The speed of light stops changing once the run has ended
+	if !ended && w.cLerp {
+		w.setC(mapRange(w.Since(w.cLerpStart), 0, w.cLerpTime.Seconds(), w.cLerpInitial, w.cLerpTarget))
+
+		if w.Since(w.cLerpStart) > w.cLerpTime.Seconds() {
+			w.setC(w.cLerpTarget)
+			w.cLerp = false
+		}
+	}
+}
+
+// substeps returns how many substeps the next tick is split into, so that no particle moves further than half its
+// radius in a substep
+func (w *World) substeps() int {
+	n := 1
+
+	check := func(p *Particle) {
+		// The distance the particle moves in a tick
+		distance := w.integrator.Speed(p, w.Ship.Vel, w.C) * w.Dt
+		if k := int(math.Ceil(distance / (p.ScaledRadius() / 2))); k > n {
+			n = k
+		}
+	}
+
+	check(w.Ship)
+
+	for _, pool := range []*Pool{w.Asteroids, w.Bullets} {
+		for _, p := range pool.Active() {
+			check(p)
+		}
+	}
+
+	return min(n, w.Config.MaxSubsteps)
+}
+
+// substep advances the simulation by h seconds
+func (w *World) substep(in Input, h float64) {
+	// Once the run has ended only the remaining particles are simulated
+	if w.Ended {
+		w.Asteroids.Update(w.Ship.Vel, w.C, h)
+		w.Bullets.Update(w.Ship.Vel, w.C, h)
+		w.Explosion.Update(w.Ship.Vel, w.C, h)
+		return
+	}
+
+	// End the run if the player asked to
+	if in.Escape {
+		log.Debug("received escape input")
+		w.End()
+	}
+
+	// Initialise the thrust direction
+	thrust := Vector{0, 0}
+
+	// If the ship is thrusting add a force proportional to the thrust input and enable thrusting flag
+	if in.Thrust > 0 {
+		thrust.X = math.Sin(w.Ship.AngPos) * in.Thrust
+		thrust.Y = -math.Cos(w.Ship.AngPos) * in.Thrust
+		w.Thrusting = true
+	} else if w.Thrusting {
+		w.Thrusting = false
+	}
+
+	// Rotate the ship
+	w.Ship.AngPos += rotateSpeed * in.Rotate * h
+
+	// Shoot a bullet, the sandbox never runs out of ammo
+	if in.Fire && (w.Ammo > 0 || w.Sandbox) {
+		w.Bullets.Activate(
+			w.Ship.Pos,
+			w.Ship.Rap.Add(Vector{
+				X: 3 * math.Sin(w.Ship.AngPos),
+				Y: 3 * -math.Cos(w.Ship.AngPos),
+			}),
+			0, 1,
+			1, 1,
+			0,
+		)
+
+		if !w.Sandbox {
+			w.Ammo--
+		}
+	}
+
+	//Update the ship
+	proper, startGamma := w.Ship.Clock, Gamma(w.Ship.Vel.Mag(), w.C)
+	w.Ship.Update(
+		thrust.Scl(w.Config.Thrust).Add(getDrag(w.Ship.Vel, w.Config.Drag)),
+		w.Ship.Vel,
+		w.C,
+		h,
+		w.integrator,
+	)
+
+	if len(w.Asteroids.Collisions(w.Ship, w.Ship.Vel, w.C)) > 0 && !w.Invincibility && !w.Sandbox {
+		log.Debug("ship hit an asteroid")
+
+		w.Invincibility = true
+		w.InvincibilityStart = w.Tick
+		w.Health--
+
+		w.Ship.AngVel += float64(w.rng.Int63n(2)*2-1) * 10
+	}
+
+	if w.Since(w.InvincibilityStart) > w.invincibilityDuration.Seconds() && w.Invincibility {
+		log.Debug("invincibility period ended")
+		w.Invincibility = false
+		w.Ship.AngVel = 0
+	}
+
+	if !w.Sandbox && (w.Health <= 0 || w.Ammo <= 0) {
+		log.Debug("game over", "health", w.Health, "ammo", w.Ammo)
+		explode(w.rng, w.Explosion, w.Ship)
+		w.End()
+	}
+
+	// Update the asteroids and solve for collisions with the ship
+	w.Asteroids.UpdateWith(w.Ship.Vel, w.C, h, w.Ship)
+
+	// Update the bullets
+	w.Bullets.Update(w.Ship.Vel, w.C, h)
+
+	// Update the explosion particles
+	w.Explosion.Update(w.Ship.Vel, w.C, h)
+
+	// Get all collisions between bullets and asteroids and for each:
+	// A bullet can overlap several asteroids and an asteroid several bullets, but each is only hit once. The slot of a
+	// destroyed asteroid can be reused by the small asteroids another one breaks into, so it is remembered as well.
+	destroyed := make(map[int]bool)
+
+	for _, ints := range w.Bullets.PoolCollisions(w.Asteroids, w.Ship.Vel, w.C) {
+		if !w.Bullets.active[ints[0]] || !w.Asteroids.active[ints[1]] || destroyed[ints[1]] {
+			continue
+		}
+
+		log.Debug("bullet hit asteroid", "bullet", ints[0], "asteroid", ints[1])
+		w.Bullets.Deactivate(ints[0]) // Remove the bullet from the game
+		// If the asteroid is a big asteroid, add three small asteroids in its place
+		reward := 1
+		if w.Asteroids.sprites[ints[1]] == 0 {
+			log.Debug("big asteroid hit, adding small asteroids")
+			parent := w.Asteroids.particles[ints[1]]
+
+			for i := 0; i < 3; i++ {
+				radius := w.rng.Float64()*0.5 + 0.5
+				mass := math.Pi * math.Pow(radius, 2)
+
+				w.Asteroids.Activate(
+					parent.Pos.Add(Vector{1, 0}.Rotate(float64(i)*math.Pi*2.0/3.0).Scl(0.2)),
+					parent.Rap.Add(Vector{1, 0}.Rotate(float64(i)*math.Pi*2.0/3.0).Scl(0.1)),
+					w.rng.Float64()*math.Pi*2,
+					w.rng.Float64()*0.25-0.125,
+					mass, radius,
+					1,
+				)
+			}
+
+			reward = 3
+		}
+
+		explode(w.rng, w.Explosion, w.Asteroids.particles[ints[1]])
+		w.Asteroids.Deactivate(ints[1]) // Remove the asteroid from the game
+		destroyed[ints[1]] = true
+
+		// The sandbox has no score or ammo, and the speed of light is only changed by hand
+		if !w.Sandbox {
+			w.Score += reward
+			w.Ammo += reward
+			w.beginCLerp(w.C/w.Config.SlowdownDivisor + w.Config.SlowdownOffset) // Begin reducing the speed of light
+		}
+	}
+
+	// Every second on the ship's clock is gamma seconds for an observer at rest, whichever time the integrator steps in,
+	// so average gamma over the substep
+	gamma := Gamma(w.Ship.Vel.Mag(), w.C)
+	w.Clock += (w.Ship.Clock - proper) * (startGamma + gamma) / 2
+	w.MaxGamma = math.Max(w.MaxGamma, gamma)
+}
@@ -0,0 +1,197 @@
+package sim
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// flyShip thrusts and turns the ship of an empty world for the given number of seconds
+func flyShip(rate int, seconds float64) *World {
+	cfg := DefaultConfig()
+	cfg.PhysicsRate = rate
+	cfg.Asteroids = 0
+
+	w := NewWorld(1, cfg)
+	for i := 0; i < int(seconds*float64(rate)); i++ {
+		w.Step(Input{Thrust: 1, Rotate: 0.2})
+	}
+
+	return w
+}
+
+func TestWorldPhysicsRateIndependent(t *testing.T) {
+	a := flyShip(60, 3)
+	b := flyShip(240, 3)
+
+	if a.Time() != b.Time() {
+		t.Fatalf("got times %f and %f", a.Time(), b.Time())
+	}
+
+	if d := a.Ship.Pos.Dist(b.Ship.Pos); d > 0.05*a.Ship.Pos.Mag() {
+		t.Fatalf("ship ended at %v at 60Hz and %v at 240Hz", a.Ship.Pos, b.Ship.Pos)
+	}
+
+	if math.Abs(a.Ship.AngPos-b.Ship.AngPos) > 1e-9 {
+		t.Fatalf("ship rotated to %f at 60Hz and %f at 240Hz", a.Ship.AngPos, b.Ship.AngPos)
+	}
+}
+
+// randomInputs returns n inputs that thrust, turn and fire at random, picked using the given seed
+func randomInputs(seed int64, n int) []Input {
+	rng := rand.New(rand.NewSource(seed))
+
+	out := make([]Input, n)
+	for i := range out {
+		out[i] = Input{
+			Thrust: rng.Float64(),
+			Rotate: rng.Float64()*2 - 1,
+			Fire:   rng.Intn(10) == 0,
+		}
+	}
+
+	return out
+}
+
+func TestWorldDeterministic(t *testing.T) {
+	cfg := DefaultConfig()
+	a, b := NewWorld(7, cfg), NewWorld(7, cfg)
+
+	for _, in := range randomInputs(1, 20*cfg.PhysicsRate) {
+		a.Step(in)
+		b.Step(in)
+	}
+
+	if a.Ammo == cfg.Ammo && a.Score == 0 {
+		t.Fatal("no bullets were fired")
+	}
+
+	if !reflect.DeepEqual(a.Ship, b.Ship) || !reflect.DeepEqual(a.ShipTrail, b.ShipTrail) {
+		t.Fatalf("ships differ: %+v and %+v", a.Ship, b.Ship)
+	}
+
+	for name, pools := range map[string][2]*Pool{
+		"asteroids": {a.Asteroids, b.Asteroids},
+		"bullets":   {a.Bullets, b.Bullets},
+		"explosion": {a.Explosion, b.Explosion},
+	} {
+		if !reflect.DeepEqual(pools[0], pools[1]) {
+			t.Fatalf("%s pools differ", name)
+		}
+	}
+
+	if a.Tick != b.Tick || a.Score != b.Score || a.Health != b.Health || a.Ammo != b.Ammo || a.C != b.C || a.Ended != b.Ended {
+		t.Fatalf("game state differs: %+v and %+v", a, b)
+	}
+
+	// Both random sources are in the same state
+	for i := 0; i < 8; i++ {
+		if x, y := a.rng.Int63(), b.rng.Int63(); x != y {
+			t.Fatalf("random sources differ: drew %d and %d", x, y)
+		}
+	}
+}
+
+func TestWorldSubsteps(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Asteroids = 0
+
+	w := NewWorld(1, cfg)
+	if n := w.substeps(); n != 1 {
+		t.Fatalf("got %d substeps for a ship at rest, want 1", n)
+	}
+
+	// A ship moving near the speed of light covers many times its size in a tick
+	w.C = 10
+	w.Ship.SetFourMomentum(NewFourMomentum(w.Ship.Mass, Vector{9.99, 0}, w.C), w.C)
+
+	if n := w.substeps(); n <= 1 {
+		t.Fatalf("got %d substeps for a near-luminal ship, want more than 1", n)
+	}
+
+	// The number of substeps is limited
+	w.Config.MaxSubsteps = 2
+	if n := w.substeps(); n != 2 {
+		t.Fatalf("got %d substeps, want the limit of 2", n)
+	}
+}
+
+func TestWorldClocks(t *testing.T) {
+	for _, integrator := range []string{"legacy", "verlet", "rk4"} {
+		cfg := DefaultConfig()
+		cfg.Asteroids = 0
+		cfg.Integrator = integrator
+		cfg.C = 12
+
+		w := NewWorld(1, cfg)
+		for i := 0; i < 600; i++ {
+			w.Step(Input{Thrust: 1})
+		}
+
+		// The ship has been moving, so less time has passed on its clock than for an observer at rest
+		if w.Ship.Clock >= w.Clock {
+			t.Errorf("%s: ship clock read %g, not behind the rest frame time %g", integrator, w.Ship.Clock, w.Clock)
+		}
+
+		// The verlet and rk4 integrators step in the time of the rest frame
+		if integrator != "legacy" && math.Abs(w.Clock-w.Time()) > 1e-5*w.Time() {
+			t.Errorf("%s: rest frame time %g, want the simulation time %g", integrator, w.Clock, w.Time())
+		}
+	}
+}
+
+func TestSandbox(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.C = 10
+
+	w := NewSandbox(1, cfg)
+	if n := len(w.Asteroids.Active()); n != 0 {
+		t.Fatalf("sandbox started with %d asteroids", n)
+	}
+
+	// Asteroids are spawned with the velocity they are given
+	p := w.SpawnAsteroid(Vector{100, 0}, Vector{0, 9})
+	if !approxEqual(p.Vel.Y, 9, 1) || p.Pos != (Vector{100, 0}) {
+		t.Fatalf("spawned asteroid at %v with velocity %v", p.Pos, p.Vel)
+	}
+
+	// Changing the speed of light keeps the rapidity, so nothing becomes faster than light
+	w.SetC(5)
+	w.Step(Input{})
+	if p.Vel.Mag() >= w.C {
+		t.Fatalf("asteroid moving at %g after lowering c to %g", p.Vel.Mag(), w.C)
+	}
+
+	// The sandbox never runs out of ammo or ends
+	ammo := w.Ammo
+	for i := 0; i < ammo+10; i++ {
+		w.Step(Input{Fire: true})
+	}
+
+	if w.Ended || w.Ammo != ammo || len(w.Bullets.Active()) != ammo+10 {
+		t.Fatalf("firing %d bullets changed the ammo to %d, ended = %v", ammo+10, w.Ammo, w.Ended)
+	}
+}
+
+func TestSandboxLowerCBelowShip(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.C = 10
+
+	w := NewSandbox(1, cfg)
+	for i := 0; i < 600; i++ {
+		w.Step(Input{Thrust: 1})
+	}
+
+	// Lowering the speed of light below the speed of the ship keeps its rapidity, so it stays slower than light
+	w.SetC(0.9 * w.Ship.Vel.Mag())
+	w.Step(Input{})
+	w.Step(Input{})
+
+	gamma := Gamma(w.Ship.Vel.Mag(), w.C)
+	for name, v := range map[string]float64{"rest frame clock": w.Clock, "ship clock": w.Ship.Clock, "ship gamma": gamma} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			t.Fatalf("%s is %g after lowering c to %g", name, v, w.C)
+		}
+	}
+}